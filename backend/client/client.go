@@ -0,0 +1,298 @@
+// Package client is a Go client for the limitlink links API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lucasmcclean/limitlink/link"
+)
+
+const (
+	// DefaultBaseURL is the address of the public limitlink instance.
+	DefaultBaseURL = "https://limitl.ink"
+
+	// defaultMaxRetries is the number of times a failed request is retried.
+	defaultMaxRetries = 3
+
+	// maxErrorBody is the number of bytes of an error response that are read.
+	maxErrorBody = 64 * 1024
+)
+
+// retryBaseDelay is the delay before the first retry. It doubles with every
+// further attempt.
+var retryBaseDelay = 200 * time.Millisecond
+
+// Client calls the links API of a limitlink server.
+// Its fields may be changed before first use, but not concurrently with it.
+type Client struct {
+	BaseURL    string       // Server address, e.g. "https://limitl.ink"
+	HTTPClient *http.Client // Client used for requests (default: http.DefaultClient)
+	MaxRetries int          // Retries of idempotent requests after network errors and 429, 502, 503, or 504 responses
+}
+
+// New returns a client for the server at baseURL.
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: http.DefaultClient,
+		MaxRetries: defaultMaxRetries,
+	}
+}
+
+// Create creates a new link. The request is sent with an idempotency key so
+// that retries never create more than one link.
+func (c *Client) Create(ctx context.Context, req *CreateRequest) (*CreateResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("encoding request: %w", err)
+	}
+
+	key, err := newIdempotencyKey()
+	if err != nil {
+		return nil, err
+	}
+
+	var resp CreateResponse
+	if err := c.do(ctx, http.MethodPost, "/links", body, key, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Get returns the current state of the link with the given admin token.
+func (c *Client) Get(ctx context.Context, adminToken string) (*link.PublicLink, error) {
+	var lnk link.PublicLink
+	if err := c.do(ctx, http.MethodGet, adminPath(adminToken), nil, "", &lnk); err != nil {
+		return nil, err
+	}
+	return &lnk, nil
+}
+
+// Patch applies a partial update to the link with the given admin token.
+func (c *Client) Patch(ctx context.Context, adminToken string, req *PatchRequest) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("encoding request: %w", err)
+	}
+	return c.do(ctx, http.MethodPatch, adminPath(adminToken), body, "", nil)
+}
+
+// Delete permanently deletes the link with the given admin token.
+//
+// A retry that finds no link reports success only if an earlier attempt may
+// have deleted it but lost the response; otherwise it returns ErrNotFound.
+func (c *Client) Delete(ctx context.Context, adminToken string) error {
+	return c.do(ctx, http.MethodDelete, adminPath(adminToken), nil, "", nil)
+}
+
+// Stats returns the usage statistics of the link with the given admin token.
+func (c *Client) Stats(ctx context.Context, adminToken string) (*link.Stats, error) {
+	var stats link.Stats
+	if err := c.do(ctx, http.MethodGet, adminPath(adminToken)+"/stats", nil, "", &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// QROptions selects the format and appearance of a QR code. Zero values use
+// the server's defaults.
+type QROptions struct {
+	Format     string // "png" or "svg"
+	Size       int    // Width and height in pixels
+	Level      string // Error correction level: L, M, Q, or H
+	Margin     int    // Quiet zone in modules
+	Foreground string // Hex color of dark modules, e.g. "000000"
+	Background string // Hex color of light modules, e.g. "ffffff"
+}
+
+// QR returns the QR code of the short URL of the link with the given admin
+// token.
+func (c *Client) QR(ctx context.Context, adminToken string, opts QROptions) ([]byte, error) {
+	query := url.Values{}
+	if opts.Format != "" {
+		query.Set("format", opts.Format)
+	}
+	if opts.Size != 0 {
+		query.Set("size", strconv.Itoa(opts.Size))
+	}
+	if opts.Level != "" {
+		query.Set("ecl", opts.Level)
+	}
+	if opts.Margin != 0 {
+		query.Set("margin", strconv.Itoa(opts.Margin))
+	}
+	if opts.Foreground != "" {
+		query.Set("fg", opts.Foreground)
+	}
+	if opts.Background != "" {
+		query.Set("bg", opts.Background)
+	}
+
+	path := adminPath(adminToken) + "/qr"
+	if len(query) != 0 {
+		path += "?" + query.Encode()
+	}
+
+	var image []byte
+	if err := c.do(ctx, http.MethodGet, path, nil, "", &image); err != nil {
+		return nil, err
+	}
+	return image, nil
+}
+
+// do sends a request, retrying transient failures, and decodes a successful
+// response into out unless it is nil. A *[]byte receives the raw body.
+func (c *Client) do(ctx context.Context, method, path string, body []byte, idempotencyKey string, out any) error {
+	retryable := isIdempotent(method, idempotencyKey)
+
+	var err error
+	var handled bool // Whether a failed attempt may have been handled anyway
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			if waitErr := sleep(ctx, retryBaseDelay<<(attempt-1)); waitErr != nil {
+				return errors.Join(err, waitErr)
+			}
+		}
+
+		var retry bool
+		retry, err = c.send(ctx, method, path, body, idempotencyKey, out)
+		if handled && method == http.MethodDelete && errors.Is(err, ErrNotFound) {
+			// An earlier attempt may have deleted the link and lost its response.
+			return nil
+		}
+		handled = handled || mayHaveBeenHandled(err)
+		if err == nil || !retry || !retryable || attempt >= c.MaxRetries {
+			return err
+		}
+	}
+}
+
+// send makes a single attempt at a request. It reports whether a failed
+// attempt may be retried.
+func (c *Client) send(ctx context.Context, method, path string, body []byte, idempotencyKey string, out any) (bool, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return false, fmt.Errorf("building request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return isRetryableStatus(resp.StatusCode), newAPIError(resp.StatusCode, msg)
+	}
+
+	if out == nil {
+		return false, nil
+	}
+	if raw, ok := out.(*[]byte); ok {
+		if *raw, err = io.ReadAll(resp.Body); err != nil {
+			return ctx.Err() == nil, fmt.Errorf("reading response: %w", err)
+		}
+		return false, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return false, fmt.Errorf("decoding response: %w", err)
+	}
+	return false, nil
+}
+
+// mayHaveBeenHandled reports whether the server may have handled a request that
+// failed with err: the connection failed or timed out after the request was
+// sent, or a gateway timed out waiting for the response. Dial errors and other
+// error responses, including 502 and 503 from a proxy, mean it was not.
+func mayHaveBeenHandled(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusGatewayTimeout
+	}
+	var opErr *net.OpError
+	return !errors.As(err, &opErr) || opErr.Op != "dial"
+}
+
+// isIdempotent reports whether a request may be sent more than once without
+// repeating its effects. PATCH is not: a repeated patch records another
+// revision or fails with a conflict.
+func isIdempotent(method, idempotencyKey string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete:
+		return true
+	case http.MethodPost:
+		return idempotencyKey != ""
+	}
+	return false
+}
+
+// isRetryableStatus reports whether a response with the given status may
+// succeed if sent again.
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// adminPath returns the API path of the link with the given admin token.
+func adminPath(adminToken string) string {
+	return "/links/" + url.PathEscape(adminToken)
+}
+
+// newIdempotencyKey returns a random key identifying a create request.
+func newIdempotencyKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating idempotency key: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
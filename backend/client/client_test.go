@@ -0,0 +1,314 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lucasmcclean/limitlink/link"
+	"github.com/lucasmcclean/limitlink/link/linktest"
+	"github.com/lucasmcclean/limitlink/server"
+)
+
+func init() {
+	retryBaseDelay = time.Millisecond
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+}
+
+// newTestServer runs the real server against an in-memory repository. If
+// wrap is non-nil, it intercepts every request before the server.
+func newTestServer(t *testing.T, wrap func(next http.Handler) http.Handler) (*Client, *linktest.Repository) {
+	t.Helper()
+
+	repo := linktest.NewRepository()
+	handler := server.New(repo, server.NewHealth(repo), server.Config{}).Handler
+	if wrap != nil {
+		handler = wrap(handler)
+	}
+
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+
+	c := New(ts.URL)
+	c.HTTPClient = ts.Client()
+	return c, repo
+}
+
+func validRequest() *CreateRequest {
+	expiresIn := link.Duration(time.Hour)
+	return &CreateRequest{
+		Target:      "https://example.com/page",
+		SlugLength:  8,
+		SlugCharset: "alphanumeric",
+		ExpiresIn:   &expiresIn,
+	}
+}
+
+func TestCreateGetPatchDelete(t *testing.T) {
+	c, _ := newTestServer(t, nil)
+	ctx := context.Background()
+
+	created, err := c.Create(ctx, validRequest())
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if len(created.Slug) != 8 || created.AdminToken == "" {
+		t.Fatalf("Create returned %+v", created)
+	}
+
+	got, err := c.Get(ctx, created.AdminToken)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Target != "https://example.com/page" || got.Slug != created.Slug {
+		t.Errorf("Get returned target %q slug %q", got.Target, got.Slug)
+	}
+
+	err = c.Patch(ctx, created.AdminToken, &PatchRequest{
+		Target:  Set("https://example.org/"),
+		MaxHits: Set(5),
+	})
+	if err != nil {
+		t.Fatalf("Patch: %v", err)
+	}
+
+	got, err = c.Get(ctx, created.AdminToken)
+	if err != nil {
+		t.Fatalf("Get after patch: %v", err)
+	}
+	if got.Target != "https://example.org/" || got.MaxHits == nil || *got.MaxHits != 5 || got.Revision != 1 {
+		t.Errorf("Get after patch returned target %q maxHits %v revision %d", got.Target, got.MaxHits, got.Revision)
+	}
+
+	if err := c.Patch(ctx, created.AdminToken, &PatchRequest{MaxHits: Null[int]()}); err != nil {
+		t.Fatalf("Patch removing maxHits: %v", err)
+	}
+
+	stats, err := c.Stats(ctx, created.AdminToken)
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.Status != link.StatusAvailable || stats.MaxHits != nil {
+		t.Errorf("Stats returned status %q maxHits %v", stats.Status, stats.MaxHits)
+	}
+
+	if err := c.Delete(ctx, created.AdminToken); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := c.Get(ctx, created.AdminToken); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after delete: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestCreateErrorMapping(t *testing.T) {
+	c, _ := newTestServer(t, nil)
+
+	tests := []struct {
+		name   string
+		modify func(*CreateRequest)
+		want   error
+	}{
+		{"bad scheme", func(r *CreateRequest) { r.Target = "ftp://example.com" }, link.ErrURLSchemeNotHTTPorHTTPS},
+		{"slug length", func(r *CreateRequest) { r.SlugLength = 3 }, link.ErrInvalidSlugLen},
+		{"charset", func(r *CreateRequest) { r.SlugCharset = "emoji" }, link.ErrUnrecognizedCharset},
+		{"negative max hits", func(r *CreateRequest) { r.MaxHits = new(int); *r.MaxHits = -1 }, link.ErrMaxHitsNegative},
+		{"conflicting expiration", func(r *CreateRequest) {
+			at := time.Now().Add(time.Hour)
+			r.ExpiresAt = &at
+		}, link.ErrConflictingExpiration},
+		{"target and variants", func(r *CreateRequest) {
+			r.Variants = []link.Variant{
+				{Name: "A", Target: "https://a.example.com", Weight: 1},
+				{Name: "B", Target: "https://b.example.com", Weight: 1},
+			}
+		}, link.ErrTargetAndVariants},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := validRequest()
+			tt.modify(req)
+
+			_, err := c.Create(context.Background(), req)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || apiErr.Code == "" {
+				t.Errorf("got %#v, want a 400 APIError with a code", err)
+			}
+		})
+	}
+}
+
+func TestPatchErrorMapping(t *testing.T) {
+	c, _ := newTestServer(t, nil)
+	ctx := context.Background()
+
+	created, err := c.Create(ctx, validRequest())
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	err = c.Patch(ctx, created.AdminToken, &PatchRequest{MaxHits: Set(-1)})
+	if !errors.Is(err, link.ErrMaxHitsNegative) {
+		t.Errorf("Patch: got %v, want ErrMaxHitsNegative", err)
+	}
+
+	err = c.Patch(ctx, "missing-token", &PatchRequest{MaxHits: Set(1)})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Patch unknown token: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestPlainTextErrorMapping(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "validation failed: "+link.ErrInvalidCountry.Error(), http.StatusBadRequest)
+	}))
+	defer ts.Close()
+
+	_, err := New(ts.URL).Create(context.Background(), validRequest())
+	if !errors.Is(err, link.ErrInvalidCountry) {
+		t.Errorf("got %v, want ErrInvalidCountry", err)
+	}
+}
+
+// failFirst answers the first n requests with status without passing them on.
+func failFirst(n int32, status int, attempts *atomic.Int32) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if attempts.Add(1) <= n {
+				w.WriteHeader(status)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestRetries(t *testing.T) {
+	var attempts atomic.Int32
+	c, _ := newTestServer(t, failFirst(2, http.StatusServiceUnavailable, &attempts))
+
+	if _, err := c.Create(context.Background(), validRequest()); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if got := attempts.Load(); got != 3 {
+		t.Errorf("got %d attempts, want 3", got)
+	}
+}
+
+func TestRetriesGiveUp(t *testing.T) {
+	var attempts atomic.Int32
+	c, _ := newTestServer(t, failFirst(100, http.StatusBadGateway, &attempts))
+	c.MaxRetries = 2
+
+	_, err := c.Get(context.Background(), "token")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("got %v, want a 502 APIError", err)
+	}
+	if got := attempts.Load(); got != 3 {
+		t.Errorf("got %d attempts, want 3", got)
+	}
+}
+
+func TestPatchNotRetried(t *testing.T) {
+	var attempts atomic.Int32
+	c, _ := newTestServer(t, failFirst(1, http.StatusServiceUnavailable, &attempts))
+
+	err := c.Patch(context.Background(), "token", &PatchRequest{MaxHits: Set(1)})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("got %v, want a 503 APIError", err)
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("got %d attempts, want 1", got)
+	}
+}
+
+// loseFirstResponse passes the first request on but answers it with 504, as
+// if a gateway had timed out waiting for the response.
+func loseFirstResponse(attempts *atomic.Int32, keys chan<- string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if keys != nil {
+				keys <- r.Header.Get("Idempotency-Key")
+			}
+			if attempts.Add(1) == 1 {
+				next.ServeHTTP(httptest.NewRecorder(), r)
+				w.WriteHeader(http.StatusGatewayTimeout)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestCreateRetryIsIdempotent(t *testing.T) {
+	var attempts atomic.Int32
+	keys := make(chan string, 2)
+	c, repo := newTestServer(t, loseFirstResponse(&attempts, keys))
+
+	created, err := c.Create(context.Background(), validRequest())
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	first, second := <-keys, <-keys
+	if first == "" || first != second {
+		t.Errorf("got idempotency keys %q and %q, want the same key", first, second)
+	}
+
+	lnk, _ := repo.GetBySlug(context.Background(), created.Slug)
+	if lnk == nil {
+		t.Fatalf("created link %q not stored", created.Slug)
+	}
+	if n := repo.Len(); n != 1 {
+		t.Errorf("repository holds %d links, want 1", n)
+	}
+}
+
+func TestDeleteRetryAfterLostResponse(t *testing.T) {
+	var attempts atomic.Int32
+	c, repo := newTestServer(t, nil)
+
+	created, err := c.Create(context.Background(), validRequest())
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	lossy, _ := newTestServer(t, nil)
+	ts := httptest.NewServer(loseFirstResponse(&attempts, nil)(server.New(repo, server.NewHealth(repo), server.Config{}).Handler))
+	defer ts.Close()
+	lossy.BaseURL = ts.URL
+
+	if err := lossy.Delete(context.Background(), created.AdminToken); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if n := repo.Len(); n != 0 {
+		t.Errorf("repository holds %d links, want 0", n)
+	}
+}
+
+func TestDeleteRetryWithWrongToken(t *testing.T) {
+	for _, status := range []int{http.StatusBadGateway, http.StatusServiceUnavailable} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			var attempts atomic.Int32
+			c, _ := newTestServer(t, failFirst(1, status, &attempts))
+
+			if err := c.Delete(context.Background(), "typo"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Delete: err = %v, want ErrNotFound", err)
+			}
+			if got := attempts.Load(); got != 2 {
+				t.Errorf("got %d attempts, want 2", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/lucasmcclean/limitlink/link"
+)
+
+var (
+	// ErrNotFound is returned when the link doesn't exist or the admin token
+	// is invalid.
+	ErrNotFound = errors.New("link not found or invalid admin token")
+
+	// ErrUnauthorized is returned when the request lacks credentials.
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrIdempotencyConflict is returned when an idempotency key is still in
+	// use by another request or was used for a different request.
+	ErrIdempotencyConflict = errors.New("idempotency key conflict")
+)
+
+// Error codes the server uses for idempotency failures.
+const (
+	codeIdempotencyKeyReused = "idempotency_key_reused"
+	codeIdempotencyKeyInUse  = "idempotency_key_in_use"
+)
+
+// APIError is an error response from the server.
+type APIError struct {
+	StatusCode int    // HTTP status code
+	Code       string // Machine-readable error code, e.g. "invalid_url_format"
+	Message    string // Error message returned by the server
+	err        error  // Matching link, client, or nil error
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s (HTTP %d)", e.Message, e.StatusCode)
+}
+
+// Unwrap returns the link package error with the response's code, or a
+// client error matching the status, so callers can use errors.Is with the
+// link package's Err* values.
+func (e *APIError) Unwrap() error {
+	return e.err
+}
+
+// newAPIError builds the error for a response with the given status and body.
+// The body is a JSON object with an error message and code, or plain text
+// from servers that don't send one.
+func newAPIError(status int, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: status}
+
+	var decoded struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}
+	if err := json.Unmarshal(body, &decoded); err == nil && decoded.Error != "" {
+		apiErr.Message = decoded.Error
+		apiErr.Code = decoded.Code
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+
+	if apiErr.err = link.ErrorForCode(apiErr.Code); apiErr.err != nil {
+		return apiErr
+	}
+	if apiErr.err = link.ErrorForMessage(apiErr.Message); apiErr.err != nil {
+		return apiErr
+	}
+
+	switch {
+	case apiErr.Code == codeIdempotencyKeyReused, apiErr.Code == codeIdempotencyKeyInUse:
+		apiErr.err = ErrIdempotencyConflict
+	case status == http.StatusNotFound:
+		apiErr.err = ErrNotFound
+	case status == http.StatusUnauthorized:
+		apiErr.err = ErrUnauthorized
+	}
+	return apiErr
+}
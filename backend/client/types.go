@@ -0,0 +1,107 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/lucasmcclean/limitlink/link"
+)
+
+// CreateRequest holds the options of a new link. Exactly one of Target or
+// Variants, and exactly one of ExpiresAt, ExpiresIn, or ValidFor are required.
+type CreateRequest struct {
+	Target      string         `json:"target,omitempty"`      // Destination URL
+	SlugLength  int            `json:"slugLength"`            // Length of the generated slug
+	SlugCharset string         `json:"slugCharset"`           // Characters used in the slug
+	ExpiresAt   *time.Time     `json:"expiresAt,omitempty"`   // Absolute expiration
+	ExpiresIn   *link.Duration `json:"expiresIn,omitempty"`   // Expiration relative to now
+	ValidFor    *link.Duration `json:"validFor,omitempty"`    // Lifetime from the start time
+	Password    *string        `json:"password,omitempty"`    // Password protecting the link
+	MaxHits     *int           `json:"maxHits,omitempty"`     // Max allowed hits
+	ValidFrom   *time.Time     `json:"validFrom,omitempty"`   // Absolute start time
+	ValidFromIn *link.Duration `json:"validFromIn,omitempty"` // Start time relative to now
+
+	SelfDestructAfter *link.Duration      `json:"selfDestructAfter,omitempty"` // Lifetime counted from the first hit
+	Schedule          *link.Schedule      `json:"schedule,omitempty"`          // Recurring availability windows
+	FallbackTarget    *string             `json:"fallbackTarget,omitempty"`    // Destination used while unavailable
+	FallbackOn        []link.Status       `json:"fallbackOn,omitempty"`        // Reasons that use the fallback
+	Variants          []link.Variant      `json:"variants,omitempty"`          // Weighted targets used instead of Target
+	StickyVariants    bool                `json:"stickyVariants,omitempty"`    // Keep visitors on their first variant
+	Rules             []link.Rule         `json:"rules,omitempty"`             // Ordered conditional targets
+	AllowedCountries  []string            `json:"allowedCountries,omitempty"`  // Countries allowed to use the link
+	BlockedCountries  []string            `json:"blockedCountries,omitempty"`  // Countries refused access
+	CountryTargets    map[string]string   `json:"countryTargets,omitempty"`    // Per-country target overrides
+	AllowedCIDRs      []string            `json:"allowedCidrs,omitempty"`      // Networks allowed to use the link
+	DeniedCIDRs       []string            `json:"deniedCidrs,omitempty"`       // Networks refused access
+	AllowedReferrers  []string            `json:"allowedReferrers,omitempty"`  // Referrer domains the link may be followed from
+	NoReferrer        link.ReferrerPolicy `json:"noReferrer,omitempty"`        // Policy for requests without a referrer
+	Passthrough       *link.Passthrough   `json:"passthrough,omitempty"`       // Forwarding of the request path and query
+	Campaign          *link.Campaign      `json:"campaign,omitempty"`          // UTM parameters added at redirect time
+	RedirectCode      int                 `json:"redirectCode,omitempty"`      // Redirect status code
+	Interstitial      *link.Interstitial  `json:"interstitial,omitempty"`      // Warning page shown before redirecting
+}
+
+// CreateResponse identifies a newly created link.
+type CreateResponse struct {
+	Slug        string `json:"slug"`
+	AdminToken  string `json:"adminToken"`
+	RedirectURL string `json:"redirectUrl"`
+	AdminURL    string `json:"adminUrl"`
+}
+
+// Nullable is a patch value that either sets a field or, if Null, removes it
+// (or resets it to its default). Use Set and Null to build one.
+type Nullable[T any] struct {
+	Value T
+	Null  bool
+}
+
+// Set returns a patch value setting a field to v.
+func Set[T any](v T) *Nullable[T] {
+	return &Nullable[T]{Value: v}
+}
+
+// Null returns a patch value removing a field.
+func Null[T any]() *Nullable[T] {
+	return &Nullable[T]{Null: true}
+}
+
+// MarshalJSON encodes the value, or null if the field is removed.
+func (n Nullable[T]) MarshalJSON() ([]byte, error) {
+	if n.Null {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.Value)
+}
+
+// PatchRequest holds partial updates to a link. Nil fields are left
+// unchanged. See link.PatchFromJSON for the fields that may be removed.
+type PatchRequest struct {
+	Target      *Nullable[string]        `json:"target,omitempty"`
+	ExpiresAt   *Nullable[time.Time]     `json:"expiresAt,omitempty"`
+	ExpiresIn   *Nullable[link.Duration] `json:"expiresIn,omitempty"`
+	ValidFor    *Nullable[link.Duration] `json:"validFor,omitempty"`
+	MaxHits     *Nullable[int]           `json:"maxHits,omitempty"`
+	ValidFrom   *Nullable[time.Time]     `json:"validFrom,omitempty"`
+	ValidFromIn *Nullable[link.Duration] `json:"validFromIn,omitempty"`
+	Password    *Nullable[string]        `json:"password,omitempty"`
+
+	SelfDestructAfter *Nullable[link.Duration]       `json:"selfDestructAfter,omitempty"`
+	Schedule          *Nullable[link.Schedule]       `json:"schedule,omitempty"`
+	FallbackTarget    *Nullable[string]              `json:"fallbackTarget,omitempty"`
+	FallbackOn        *Nullable[[]link.Status]       `json:"fallbackOn,omitempty"`
+	Variants          *Nullable[[]link.Variant]      `json:"variants,omitempty"`
+	StickyVariants    *Nullable[bool]                `json:"stickyVariants,omitempty"`
+	Rules             *Nullable[[]link.Rule]         `json:"rules,omitempty"`
+	AllowedCountries  *Nullable[[]string]            `json:"allowedCountries,omitempty"`
+	BlockedCountries  *Nullable[[]string]            `json:"blockedCountries,omitempty"`
+	CountryTargets    *Nullable[map[string]string]   `json:"countryTargets,omitempty"`
+	AllowedCIDRs      *Nullable[[]string]            `json:"allowedCidrs,omitempty"`
+	DeniedCIDRs       *Nullable[[]string]            `json:"deniedCidrs,omitempty"`
+	AllowedReferrers  *Nullable[[]string]            `json:"allowedReferrers,omitempty"`
+	NoReferrer        *Nullable[link.ReferrerPolicy] `json:"noReferrer,omitempty"`
+	Passthrough       *Nullable[link.Passthrough]    `json:"passthrough,omitempty"`
+	Campaign          *Nullable[link.Campaign]       `json:"campaign,omitempty"`
+	RedirectCode      *Nullable[int]                 `json:"redirectCode,omitempty"`
+	Interstitial      *Nullable[link.Interstitial]   `json:"interstitial,omitempty"`
+}
@@ -0,0 +1,401 @@
+// Command limitlink-admin lets operators maintain the links database
+// directly, without going through the public API.
+//
+// It connects using the same MONGO_URI and MONGO_NAME environment variables as
+// the server. Commands that change data accept -dry-run to report what they
+// would do instead, and every command accepts -json for scripting.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lucasmcclean/limitlink/link"
+	"github.com/lucasmcclean/limitlink/mongo"
+)
+
+// store is the database access the commands need.
+type store interface {
+	link.Repository
+	link.Maintainer
+}
+
+// command is a limitlink-admin subcommand.
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, links store, args []string) error
+}
+
+var commands = []command{
+	{"find", "list links that redirect to a domain", runFind},
+	{"disable", "take links down by slug or target domain", runDisable},
+	{"enable", "restore disabled links", runEnable},
+	{"purge", "delete links whose admin access has expired", runPurge},
+	{"reindex", "create missing indexes", runReindex},
+	{"check", "report duplicate slugs, missing fields, and unsupported schema versions", runCheck},
+}
+
+// errIssuesFound makes the process exit with status 1 without printing an
+// error, after a report that already describes the problem.
+var errIssuesFound = errors.New("issues found")
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "-help" || os.Args[1] == "help" {
+		usage()
+		return
+	}
+
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == os.Args[1] {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "limitlink-admin: unknown command %q\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	db, err := mongo.New(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "limitlink-admin: %v\n", err)
+		os.Exit(1)
+	}
+
+	err = cmd.run(ctx, db.RawLinks(), os.Args[2:])
+	_ = db.Close(context.Background())
+
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+	case errors.Is(err, errIssuesFound):
+		os.Exit(1)
+	default:
+		fmt.Fprintf(os.Stderr, "limitlink-admin %s: %v\n", cmd.name, err)
+		os.Exit(1)
+	}
+}
+
+// usage prints the list of subcommands.
+func usage() {
+	var b strings.Builder
+	b.WriteString("Usage: limitlink-admin <command> [flags] [args]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(&b, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	b.WriteString("\nRun limitlink-admin <command> -h for the flags of a command.\n")
+	fmt.Fprint(os.Stderr, b.String())
+}
+
+// options are the flags shared by every subcommand.
+type options struct {
+	json   bool
+	dryRun bool
+}
+
+// newFlagSet returns a flag set for the named subcommand with -json and, for
+// commands that change data, -dry-run registered.
+func newFlagSet(name, args string, changes bool) (*flag.FlagSet, *options) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: limitlink-admin %s [flags] %s\n\nFlags:\n", name, args)
+		fs.PrintDefaults()
+	}
+
+	opts := &options{}
+	fs.BoolVar(&opts.json, "json", false, "print JSON instead of text")
+	if changes {
+		fs.BoolVar(&opts.dryRun, "dry-run", false, "report what would change without changing anything")
+	}
+	return fs, opts
+}
+
+// linkSummary is the report entry of a single link.
+type linkSummary struct {
+	Slug      string      `json:"slug"`
+	Status    link.Status `json:"status"`
+	Hosts     []string    `json:"hosts"`
+	HitCount  int         `json:"hitCount"`
+	CreatedAt time.Time   `json:"createdAt"`
+	ExpiresAt time.Time   `json:"expiresAt"`
+}
+
+// summarize returns the report entry of lnk.
+func summarize(lnk *link.Link, now time.Time) linkSummary {
+	return linkSummary{
+		Slug:      lnk.Slug,
+		Status:    lnk.Status(now),
+		Hosts:     lnk.DestinationHosts(),
+		HitCount:  lnk.HitCount,
+		CreatedAt: lnk.CreatedAt,
+		ExpiresAt: lnk.EffectiveExpiresAt(),
+	}
+}
+
+// printLinks writes one row per link.
+func printLinks(w io.Writer, links []linkSummary) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SLUG\tSTATUS\tHITS\tEXPIRES\tHOSTS")
+	for _, l := range links {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", l.Slug, l.Status, l.HitCount, l.ExpiresAt.UTC().Format(time.RFC3339), strings.Join(l.Hosts, ", "))
+	}
+	return tw.Flush()
+}
+
+// printJSON writes v as indented JSON.
+func printJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// runFind lists the links that redirect to a domain.
+func runFind(ctx context.Context, links store, args []string) error {
+	fs, opts := newFlagSet("find", "<domain>", false)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("exactly one domain is required")
+	}
+
+	found, err := links.FindByTargetDomain(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	summaries := make([]linkSummary, 0, len(found))
+	for i := range found {
+		summaries = append(summaries, summarize(&found[i], now))
+	}
+
+	if opts.json {
+		return printJSON(os.Stdout, summaries)
+	}
+	return printLinks(os.Stdout, summaries)
+}
+
+// toggleResult reports the outcome of disable and enable.
+type toggleResult struct {
+	DryRun   bool     `json:"dryRun"`
+	Changed  []string `json:"changed"`
+	NotFound []string `json:"notFound,omitempty"`
+}
+
+// runDisable takes down the links with the given slugs or, with -domain,
+// every link redirecting to a domain.
+func runDisable(ctx context.Context, links store, args []string) error {
+	fs, opts := newFlagSet("disable", "[slug...]", true)
+	domain := fs.String("domain", "", "also disable every link that redirects to this domain")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	slugs := fs.Args()
+	if *domain != "" {
+		found, err := links.FindByTargetDomain(ctx, *domain)
+		if err != nil {
+			return err
+		}
+		for _, lnk := range found {
+			slugs = append(slugs, lnk.Slug)
+		}
+	}
+	if len(slugs) == 0 && *domain == "" {
+		return errors.New("a slug or -domain is required")
+	}
+
+	now := time.Now()
+	return toggle(ctx, links, slugs, opts, "disabled", func(slug string) (bool, error) {
+		return links.DisableBySlug(ctx, slug, now)
+	})
+}
+
+// runEnable restores disabled links.
+func runEnable(ctx context.Context, links store, args []string) error {
+	fs, opts := newFlagSet("enable", "<slug...>", true)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("at least one slug is required")
+	}
+
+	return toggle(ctx, links, fs.Args(), opts, "enabled", func(slug string) (bool, error) {
+		return links.EnableBySlug(ctx, slug)
+	})
+}
+
+// toggle applies change to each slug, or only looks the slugs up in a dry
+// run, and reports the outcome.
+func toggle(ctx context.Context, links store, slugs []string, opts *options, verb string, change func(slug string) (bool, error)) error {
+	result := toggleResult{DryRun: opts.dryRun, Changed: []string{}}
+
+	for _, slug := range slugs {
+		var found bool
+		if opts.dryRun {
+			lnk, err := links.GetBySlug(ctx, slug)
+			if err != nil {
+				return fmt.Errorf("%s: %w", slug, err)
+			}
+			found = lnk != nil
+		} else {
+			var err error
+			if found, err = change(slug); err != nil {
+				return fmt.Errorf("%s: %w", slug, err)
+			}
+		}
+
+		if found {
+			result.Changed = append(result.Changed, slug)
+		} else {
+			result.NotFound = append(result.NotFound, slug)
+		}
+	}
+
+	if opts.json {
+		return printJSON(os.Stdout, result)
+	}
+
+	prefix := ""
+	if opts.dryRun {
+		prefix = "would be "
+	}
+	for _, slug := range result.Changed {
+		fmt.Printf("%s: %s%s\n", slug, prefix, verb)
+	}
+	for _, slug := range result.NotFound {
+		fmt.Printf("%s: not found\n", slug)
+	}
+	return nil
+}
+
+// runPurge deletes the links whose admin access has expired.
+func runPurge(ctx context.Context, links store, args []string) error {
+	fs, opts := newFlagSet("purge", "", true)
+	beforeFlag := fs.String("before", "", "RFC3339 cutoff for admin expiry (default: now)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	before := time.Now()
+	if *beforeFlag != "" {
+		var err error
+		if before, err = time.Parse(time.RFC3339, *beforeFlag); err != nil {
+			return errors.New("-before: expected an RFC3339 time such as 2025-07-01T12:00:00Z")
+		}
+	}
+
+	var count int64
+	var err error
+	if opts.dryRun {
+		count, err = links.CountExpired(ctx, before)
+	} else {
+		count, err = links.PurgeExpired(ctx, before)
+	}
+	if err != nil {
+		return err
+	}
+
+	if opts.json {
+		return printJSON(os.Stdout, struct {
+			DryRun bool      `json:"dryRun"`
+			Before time.Time `json:"before"`
+			Count  int64     `json:"count"`
+		}{opts.dryRun, before, count})
+	}
+	if opts.dryRun {
+		fmt.Printf("%d expired links would be deleted\n", count)
+	} else {
+		fmt.Printf("%d expired links deleted\n", count)
+	}
+	return nil
+}
+
+// runReindex creates the indexes the links collection is missing.
+func runReindex(ctx context.Context, links store, args []string) error {
+	fs, opts := newFlagSet("reindex", "", true)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	missing, err := links.MissingIndexes(ctx)
+	if err != nil {
+		return err
+	}
+	if len(missing) != 0 && !opts.dryRun {
+		if err := links.EnsureIndexes(ctx); err != nil {
+			return fmt.Errorf("%w (run check to find duplicates blocking unique indexes)", err)
+		}
+	}
+
+	if opts.json {
+		return printJSON(os.Stdout, struct {
+			DryRun  bool     `json:"dryRun"`
+			Missing []string `json:"missing"`
+		}{opts.dryRun, missing})
+	}
+	switch {
+	case len(missing) == 0:
+		fmt.Println("all indexes present")
+	case opts.dryRun:
+		fmt.Printf("would create: %s\n", strings.Join(missing, ", "))
+	default:
+		fmt.Printf("created: %s\n", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// runCheck reports links that break data invariants. It exits with status 1
+// if any are found.
+func runCheck(ctx context.Context, links store, args []string) error {
+	fs, opts := newFlagSet("check", "", false)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	issues, err := links.CheckIntegrity(ctx)
+	if err != nil {
+		return err
+	}
+
+	if opts.json {
+		if issues == nil {
+			issues = []link.IntegrityIssue{}
+		}
+		if err := printJSON(os.Stdout, issues); err != nil {
+			return err
+		}
+	} else if len(issues) == 0 {
+		fmt.Println("no issues found")
+	} else {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "KIND\tID\tSLUG\tDETAIL")
+		for _, issue := range issues {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", issue.Kind, issue.ID, issue.Slug, issue.Detail)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if len(issues) != 0 {
+		return errIssuesFound
+	}
+	return nil
+}
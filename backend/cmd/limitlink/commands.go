@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/lucasmcclean/limitlink/client"
+)
+
+// runCreate creates a link and stores its admin token in the keyring.
+func runCreate(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("create", "")
+	slugLength := fs.Int("slug-length", 8, "length of the generated slug (6-12)")
+	slugCharset := fs.String("slug-charset", "alphanumeric", "slug characters: letters, numbers, or alphanumeric")
+	opts := addLinkOptions(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	req := &client.CreateRequest{SlugLength: *slugLength, SlugCharset: *slugCharset}
+	if err := opts.createRequest(req); err != nil {
+		return err
+	}
+
+	c, err := common.client()
+	if err != nil {
+		return err
+	}
+	kr, err := openKeyring()
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.Create(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	kr.Entries[resp.Slug] = keyEntry{
+		AdminToken:  resp.AdminToken,
+		Server:      c.BaseURL,
+		RedirectURL: resp.RedirectURL,
+		AdminURL:    resp.AdminURL,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := kr.save(); err != nil {
+		// The link exists, so print it anyway rather than lose the token.
+		fmt.Fprintf(os.Stderr, "limitlink create: %v\n", err)
+	}
+
+	return output(os.Stdout, common.json, resp)
+}
+
+// runGet prints the current state of a link.
+func runGet(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("get", "<slug>")
+	t := addTargetFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	c, token, _, _, err := setup(fs, common, t)
+	if err != nil {
+		return err
+	}
+
+	lnk, err := c.Get(ctx, token)
+	if err != nil {
+		return err
+	}
+	return output(os.Stdout, common.json, lnk)
+}
+
+// runPatch applies the settings given on the command line to a link.
+func runPatch(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("patch", "<slug>")
+	t := addTargetFlags(fs)
+	opts := addLinkOptions(fs)
+	unset := fs.String("unset", "", "comma-separated settings to remove, by flag name (e.g. max-hits,rules)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	req, err := opts.patchRequest(list(*unset))
+	if err != nil {
+		return err
+	}
+
+	c, token, _, _, err := setup(fs, common, t)
+	if err != nil {
+		return err
+	}
+
+	if err := c.Patch(ctx, token, req); err != nil {
+		return err
+	}
+
+	lnk, err := c.Get(ctx, token)
+	if err != nil {
+		return err
+	}
+	return output(os.Stdout, common.json, lnk)
+}
+
+// runDelete deletes a link and forgets its admin token.
+func runDelete(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("delete", "<slug>")
+	t := addTargetFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	c, token, slug, kr, err := setup(fs, common, t)
+	if err != nil {
+		return err
+	}
+
+	err = c.Delete(ctx, token)
+	if err != nil && !(errors.Is(err, client.ErrNotFound) && slug != "") {
+		return err
+	}
+
+	if _, ok := kr.Entries[slug]; ok {
+		delete(kr.Entries, slug)
+		if err := kr.save(); err != nil {
+			return err
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%w (removed from the keyring)", err)
+	}
+	return nil
+}
+
+// runStats prints the usage statistics of a link.
+func runStats(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("stats", "<slug>")
+	t := addTargetFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	c, token, _, _, err := setup(fs, common, t)
+	if err != nil {
+		return err
+	}
+
+	stats, err := c.Stats(ctx, token)
+	if err != nil {
+		return err
+	}
+	return output(os.Stdout, common.json, stats)
+}
+
+// runQR writes the QR code of a link's short URL to a file or stdout.
+func runQR(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("qr", "<slug>")
+	t := addTargetFlags(fs)
+	var opts client.QROptions
+	fs.StringVar(&opts.Format, "format", "png", "image format: png or svg")
+	fs.IntVar(&opts.Size, "size", 0, "width and height in pixels")
+	fs.StringVar(&opts.Level, "ecl", "", "error correction level: L, M, Q, or H")
+	fs.IntVar(&opts.Margin, "margin", 0, "quiet zone in modules")
+	fs.StringVar(&opts.Foreground, "fg", "", "hex color of dark modules")
+	fs.StringVar(&opts.Background, "bg", "", "hex color of light modules")
+	out := fs.String("o", "", "output file (default: stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	c, token, _, _, err := setup(fs, common, t)
+	if err != nil {
+		return err
+	}
+
+	image, err := c.QR(ctx, token, opts)
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		_, err = os.Stdout.Write(image)
+		return err
+	}
+	return os.WriteFile(*out, image, 0o644)
+}
+
+// runOpen opens a link's short URL, or its admin page, in the browser.
+func runOpen(ctx context.Context, args []string) error {
+	fs, _ := newFlagSet("open", "<slug>")
+	admin := fs.Bool("admin", false, "open the admin page instead of the short URL")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("exactly one slug is required")
+	}
+
+	kr, err := openKeyring()
+	if err != nil {
+		return err
+	}
+	entry, ok := kr.Entries[fs.Arg(0)]
+	if !ok {
+		return fmt.Errorf("%s: %w", fs.Arg(0), errUnknownSlug)
+	}
+
+	url := entry.RedirectURL
+	if *admin {
+		url = entry.AdminURL
+	}
+	return openBrowser(ctx, url)
+}
+
+// setup resolves the selected link and returns a client for the server.
+func setup(fs *flag.FlagSet, common *commonFlags, t *target) (c *client.Client, token, slug string, kr *keyring, err error) {
+	kr, err = openKeyring()
+	if err != nil {
+		return nil, "", "", nil, err
+	}
+
+	token, slug, err = t.resolve(fs, kr)
+	if err != nil {
+		return nil, "", "", nil, err
+	}
+
+	// Links from the keyring are managed on the server they were created on.
+	if entry, ok := kr.Entries[slug]; ok && common.server == "" && t.token == "" {
+		common.server = entry.Server
+	}
+
+	c, err = common.client()
+	if err != nil {
+		return nil, "", "", nil, err
+	}
+	return c, token, slug, kr, nil
+}
+
+// openBrowser opens url with the platform's default handler.
+func openBrowser(ctx context.Context, url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.CommandContext(ctx, "open", url)
+	case "windows":
+		cmd = exec.CommandContext(ctx, "rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.CommandContext(ctx, "xdg-open", url)
+	}
+	cmd.Stdout = io.Discard
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("opening %s: %w", url, err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/lucasmcclean/limitlink/client"
+)
+
+// config holds the settings read from the configuration file.
+type config struct {
+	Server string `json:"server"` // limitlink server address
+}
+
+// configDir returns the directory holding the configuration and keyring
+// files. It can be overridden with LIMITLINK_CONFIG_DIR.
+func configDir() (string, error) {
+	if dir := os.Getenv("LIMITLINK_CONFIG_DIR"); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("locating config directory: %w", err)
+	}
+	return filepath.Join(dir, "limitlink"), nil
+}
+
+// loadConfig reads config.json from the config directory. A missing file
+// yields the defaults. LIMITLINK_SERVER overrides the configured server.
+func loadConfig() (*config, error) {
+	cfg := &config{Server: client.DefaultBaseURL}
+
+	dir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("reading config: %w", err)
+	default:
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("parsing config: %w", err)
+		}
+	}
+
+	if server := os.Getenv("LIMITLINK_SERVER"); server != "" {
+		cfg.Server = server
+	}
+	return cfg, nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// errUnknownSlug is returned when the keyring has no admin token for a slug.
+var errUnknownSlug = errors.New("no admin token stored for this slug (use -token)")
+
+// keyEntry is the keyring record of a link created from this machine.
+type keyEntry struct {
+	AdminToken  string    `json:"adminToken"`
+	Server      string    `json:"server"`
+	RedirectURL string    `json:"redirectUrl"`
+	AdminURL    string    `json:"adminUrl"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// keyring maps slugs to the admin tokens of their links. It is stored as
+// keyring.json in the config directory, readable only by the owner.
+type keyring struct {
+	path    string
+	Entries map[string]keyEntry `json:"links"`
+}
+
+// openKeyring reads the keyring, or returns an empty one if it doesn't exist.
+func openKeyring() (*keyring, error) {
+	dir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+
+	kr := &keyring{path: filepath.Join(dir, "keyring.json"), Entries: map[string]keyEntry{}}
+
+	data, err := os.ReadFile(kr.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return kr, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading keyring: %w", err)
+	}
+	if err := json.Unmarshal(data, kr); err != nil {
+		return nil, fmt.Errorf("parsing keyring %s: %w", kr.path, err)
+	}
+	if kr.Entries == nil {
+		kr.Entries = map[string]keyEntry{}
+	}
+	return kr, nil
+}
+
+// save writes the keyring atomically with owner-only permissions.
+func (kr *keyring) save() error {
+	data, err := json.MarshalIndent(kr, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding keyring: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(kr.path), 0o700); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(kr.path), ".keyring-*.json")
+	if err != nil {
+		return fmt.Errorf("writing keyring: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing keyring: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing keyring: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), kr.path); err != nil {
+		return fmt.Errorf("writing keyring: %w", err)
+	}
+	return nil
+}
+
+// token returns the admin token stored for slug.
+func (kr *keyring) token(slug string) (string, error) {
+	entry, ok := kr.Entries[slug]
+	if !ok {
+		return "", errUnknownSlug
+	}
+	return entry.AdminToken, nil
+}
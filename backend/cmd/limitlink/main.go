@@ -0,0 +1,146 @@
+// Command limitlink creates and manages links on a limitlink server.
+//
+// Admin tokens of links created with it are kept in a keyring file so that
+// later commands can refer to links by slug. The server address is read from
+// config.json in the config directory, LIMITLINK_SERVER, or the -server flag.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/lucasmcclean/limitlink/client"
+)
+
+// command is a limitlink subcommand.
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"create", "create a link and store its admin token", runCreate},
+	{"get", "show a link", runGet},
+	{"patch", "update a link", runPatch},
+	{"delete", "delete a link", runDelete},
+	{"stats", "show a link's usage statistics", runStats},
+	{"qr", "write a link's QR code", runQR},
+	{"open", "open a link or its admin page in the browser", runOpen},
+}
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "-help" || os.Args[1] == "help" {
+		usage()
+		return
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != os.Args[1] {
+			continue
+		}
+		err := cmd.run(ctx, os.Args[2:])
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "limitlink %s: %v\n", cmd.name, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "limitlink: unknown command %q\n\n", os.Args[1])
+	usage()
+	os.Exit(2)
+}
+
+// usage prints the list of subcommands.
+func usage() {
+	var b strings.Builder
+	b.WriteString("Usage: limitlink <command> [flags] [slug]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(&b, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	b.WriteString("\nRun limitlink <command> -h for the flags of a command.\n")
+	fmt.Fprint(os.Stderr, b.String())
+}
+
+// commonFlags are the flags shared by every subcommand.
+type commonFlags struct {
+	server string
+	json   bool
+}
+
+// newFlagSet returns a flag set for the named subcommand with the common
+// flags registered.
+func newFlagSet(name, args string) (*flag.FlagSet, *commonFlags) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: limitlink %s [flags] %s\n\nFlags:\n", name, args)
+		fs.PrintDefaults()
+	}
+
+	common := &commonFlags{}
+	fs.StringVar(&common.server, "server", "", "server address (default: from config)")
+	fs.BoolVar(&common.json, "json", false, "print JSON instead of a table")
+	return fs, common
+}
+
+// client returns an API client for the selected server.
+func (common *commonFlags) client() (*client.Client, error) {
+	if common.server == "" {
+		cfg, err := loadConfig()
+		if err != nil {
+			return nil, err
+		}
+		common.server = cfg.Server
+	}
+	return client.New(common.server), nil
+}
+
+// target is a link selected by slug (looked up in the keyring) or by admin
+// token.
+type target struct {
+	token string
+}
+
+// addTargetFlags registers the -token flag.
+func addTargetFlags(fs *flag.FlagSet) *target {
+	t := &target{}
+	fs.StringVar(&t.token, "token", "", "admin token (default: looked up by slug in the keyring)")
+	return t
+}
+
+// resolve returns the admin token and slug of the selected link. The slug is
+// empty if the link was selected by token alone.
+func (t *target) resolve(fs *flag.FlagSet, kr *keyring) (token, slug string, err error) {
+	switch fs.NArg() {
+	case 0:
+		if t.token == "" {
+			return "", "", errors.New("a slug or -token is required")
+		}
+		return t.token, "", nil
+	case 1:
+		slug = fs.Arg(0)
+	default:
+		return "", "", fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args()[1:], " "))
+	}
+
+	if t.token != "" {
+		return t.token, slug, nil
+	}
+	token, err = kr.token(slug)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", slug, err)
+	}
+	return token, slug, nil
+}
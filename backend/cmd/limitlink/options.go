@@ -0,0 +1,375 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lucasmcclean/limitlink/client"
+	"github.com/lucasmcclean/limitlink/link"
+)
+
+// linkOptions holds the link settings shared by create and patch. Structured
+// settings are given as JSON in the same form the API accepts.
+type linkOptions struct {
+	fs *flag.FlagSet
+
+	target            string
+	expiresAt         string
+	expiresIn         string
+	validFor          string
+	password          string
+	maxHits           int
+	validFrom         string
+	validFromIn       string
+	selfDestructAfter string
+	schedule          string
+	fallbackTarget    string
+	fallbackOn        string
+	variants          string
+	stickyVariants    bool
+	rules             string
+	allowedCountries  string
+	blockedCountries  string
+	countryTargets    string
+	allowedCIDRs      string
+	deniedCIDRs       string
+	allowedReferrers  string
+	noReferrer        string
+	passthrough       string
+	campaign          string
+	redirectCode      int
+	interstitial      string
+}
+
+// addLinkOptions registers the link setting flags on fs.
+func addLinkOptions(fs *flag.FlagSet) *linkOptions {
+	o := &linkOptions{fs: fs}
+	fs.StringVar(&o.target, "target", "", "destination URL")
+	fs.StringVar(&o.expiresAt, "expires-at", "", "RFC3339 expiration time")
+	fs.StringVar(&o.expiresIn, "expires-in", "", "expiration relative to now, e.g. P7D, 36h, or a number of days")
+	fs.StringVar(&o.validFor, "valid-for", "", "lifetime counted from the start time")
+	fs.StringVar(&o.password, "password", "", "password protecting the link")
+	fs.IntVar(&o.maxHits, "max-hits", 0, "maximum number of redirects")
+	fs.StringVar(&o.validFrom, "valid-from", "", "RFC3339 start time")
+	fs.StringVar(&o.validFromIn, "valid-from-in", "", "start time relative to now")
+	fs.StringVar(&o.selfDestructAfter, "self-destruct-after", "", "lifetime counted from the first hit")
+	fs.StringVar(&o.schedule, "schedule", "", "recurring availability windows (JSON)")
+	fs.StringVar(&o.fallbackTarget, "fallback-target", "", "destination used while the link is unavailable")
+	fs.StringVar(&o.fallbackOn, "fallback-on", "", "comma-separated unavailability reasons that use the fallback")
+	fs.StringVar(&o.variants, "variants", "", "weighted targets used instead of -target (JSON)")
+	fs.BoolVar(&o.stickyVariants, "sticky-variants", false, "keep visitors on their first variant")
+	fs.StringVar(&o.rules, "rules", "", "ordered conditional targets (JSON)")
+	fs.StringVar(&o.allowedCountries, "allowed-countries", "", "comma-separated countries allowed to use the link")
+	fs.StringVar(&o.blockedCountries, "blocked-countries", "", "comma-separated countries refused access")
+	fs.StringVar(&o.countryTargets, "country-targets", "", "per-country targets, e.g. DE=https://example.de,FR=https://example.fr")
+	fs.StringVar(&o.allowedCIDRs, "allowed-cidrs", "", "comma-separated networks allowed to use the link")
+	fs.StringVar(&o.deniedCIDRs, "denied-cidrs", "", "comma-separated networks refused access")
+	fs.StringVar(&o.allowedReferrers, "allowed-referrers", "", "comma-separated referrer domains the link may be followed from")
+	fs.StringVar(&o.noReferrer, "no-referrer", "", "policy for requests without a referrer: allow or deny")
+	fs.StringVar(&o.passthrough, "passthrough", "", "forwarding of the request path and query (JSON)")
+	fs.StringVar(&o.campaign, "campaign", "", "UTM parameters added at redirect time (JSON)")
+	fs.IntVar(&o.redirectCode, "redirect-code", 0, "redirect status code: 302, 303, or 307")
+	fs.StringVar(&o.interstitial, "interstitial", "", "warning page shown before redirecting (JSON)")
+	return o
+}
+
+// isSet reports whether the named flag was given on the command line.
+func (o *linkOptions) isSet(name string) bool {
+	found := false
+	o.fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			found = true
+		}
+	})
+	return found
+}
+
+// createRequest fills req with the settings given on the command line.
+func (o *linkOptions) createRequest(req *client.CreateRequest) error {
+	var err error
+	req.Target = o.target
+	req.StickyVariants = o.stickyVariants
+	req.NoReferrer = link.ReferrerPolicy(o.noReferrer)
+	req.RedirectCode = o.redirectCode
+	req.FallbackOn = statuses(o.fallbackOn)
+	req.AllowedCountries = list(o.allowedCountries)
+	req.BlockedCountries = list(o.blockedCountries)
+	req.AllowedCIDRs = list(o.allowedCIDRs)
+	req.DeniedCIDRs = list(o.deniedCIDRs)
+	req.AllowedReferrers = list(o.allowedReferrers)
+
+	if o.isSet("password") {
+		req.Password = &o.password
+	}
+	if o.isSet("max-hits") {
+		req.MaxHits = &o.maxHits
+	}
+	if o.isSet("fallback-target") {
+		req.FallbackTarget = &o.fallbackTarget
+	}
+
+	if req.ExpiresAt, err = optionalTime("expires-at", o.expiresAt); err != nil {
+		return err
+	}
+	if req.ValidFrom, err = optionalTime("valid-from", o.validFrom); err != nil {
+		return err
+	}
+	if req.ExpiresIn, err = optionalDuration("expires-in", o.expiresIn); err != nil {
+		return err
+	}
+	if req.ValidFor, err = optionalDuration("valid-for", o.validFor); err != nil {
+		return err
+	}
+	if req.ValidFromIn, err = optionalDuration("valid-from-in", o.validFromIn); err != nil {
+		return err
+	}
+	if req.SelfDestructAfter, err = optionalDuration("self-destruct-after", o.selfDestructAfter); err != nil {
+		return err
+	}
+	if req.CountryTargets, err = pairs("country-targets", o.countryTargets); err != nil {
+		return err
+	}
+
+	if err := optionalJSON("schedule", o.schedule, &req.Schedule); err != nil {
+		return err
+	}
+	if err := optionalJSON("variants", o.variants, &req.Variants); err != nil {
+		return err
+	}
+	if err := optionalJSON("rules", o.rules, &req.Rules); err != nil {
+		return err
+	}
+	if err := optionalJSON("passthrough", o.passthrough, &req.Passthrough); err != nil {
+		return err
+	}
+	if err := optionalJSON("campaign", o.campaign, &req.Campaign); err != nil {
+		return err
+	}
+	return optionalJSON("interstitial", o.interstitial, &req.Interstitial)
+}
+
+// patchRequest builds a patch from the settings given on the command line.
+// Settings named in unset are removed or reset to their defaults.
+func (o *linkOptions) patchRequest(unset []string) (*client.PatchRequest, error) {
+	req := &client.PatchRequest{}
+	var err error
+
+	o.fs.Visit(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+		switch f.Name {
+		case "target":
+			req.Target = client.Set(o.target)
+		case "expires-at":
+			req.ExpiresAt, err = patchValue(optionalTime(f.Name, o.expiresAt))
+		case "expires-in":
+			req.ExpiresIn, err = patchValue(optionalDuration(f.Name, o.expiresIn))
+		case "valid-for":
+			req.ValidFor, err = patchValue(optionalDuration(f.Name, o.validFor))
+		case "password":
+			req.Password = client.Set(o.password)
+		case "max-hits":
+			req.MaxHits = client.Set(o.maxHits)
+		case "valid-from":
+			req.ValidFrom, err = patchValue(optionalTime(f.Name, o.validFrom))
+		case "valid-from-in":
+			req.ValidFromIn, err = patchValue(optionalDuration(f.Name, o.validFromIn))
+		case "self-destruct-after":
+			req.SelfDestructAfter, err = patchValue(optionalDuration(f.Name, o.selfDestructAfter))
+		case "schedule":
+			req.Schedule, err = patchJSON[link.Schedule](f.Name, o.schedule)
+		case "fallback-target":
+			req.FallbackTarget = client.Set(o.fallbackTarget)
+		case "fallback-on":
+			req.FallbackOn = client.Set(statuses(o.fallbackOn))
+		case "variants":
+			req.Variants, err = patchJSON[[]link.Variant](f.Name, o.variants)
+		case "sticky-variants":
+			req.StickyVariants = client.Set(o.stickyVariants)
+		case "rules":
+			req.Rules, err = patchJSON[[]link.Rule](f.Name, o.rules)
+		case "allowed-countries":
+			req.AllowedCountries = client.Set(list(o.allowedCountries))
+		case "blocked-countries":
+			req.BlockedCountries = client.Set(list(o.blockedCountries))
+		case "country-targets":
+			var targets map[string]string
+			targets, err = pairs(f.Name, o.countryTargets)
+			req.CountryTargets = client.Set(targets)
+		case "allowed-cidrs":
+			req.AllowedCIDRs = client.Set(list(o.allowedCIDRs))
+		case "denied-cidrs":
+			req.DeniedCIDRs = client.Set(list(o.deniedCIDRs))
+		case "allowed-referrers":
+			req.AllowedReferrers = client.Set(list(o.allowedReferrers))
+		case "no-referrer":
+			req.NoReferrer = client.Set(link.ReferrerPolicy(o.noReferrer))
+		case "passthrough":
+			req.Passthrough, err = patchJSON[link.Passthrough](f.Name, o.passthrough)
+		case "campaign":
+			req.Campaign, err = patchJSON[link.Campaign](f.Name, o.campaign)
+		case "redirect-code":
+			req.RedirectCode = client.Set(o.redirectCode)
+		case "interstitial":
+			req.Interstitial, err = patchJSON[link.Interstitial](f.Name, o.interstitial)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range unset {
+		if o.isSet(name) {
+			return nil, fmt.Errorf("-%s cannot be both set and unset", name)
+		}
+		switch name {
+		case "max-hits":
+			req.MaxHits = client.Null[int]()
+		case "valid-from":
+			req.ValidFrom = client.Null[time.Time]()
+		case "password":
+			req.Password = client.Null[string]()
+		case "self-destruct-after":
+			req.SelfDestructAfter = client.Null[link.Duration]()
+		case "schedule":
+			req.Schedule = client.Null[link.Schedule]()
+		case "fallback-target":
+			req.FallbackTarget = client.Null[string]()
+		case "fallback-on":
+			req.FallbackOn = client.Null[[]link.Status]()
+		case "variants":
+			req.Variants = client.Null[[]link.Variant]()
+		case "rules":
+			req.Rules = client.Null[[]link.Rule]()
+		case "allowed-countries":
+			req.AllowedCountries = client.Null[[]string]()
+		case "blocked-countries":
+			req.BlockedCountries = client.Null[[]string]()
+		case "country-targets":
+			req.CountryTargets = client.Null[map[string]string]()
+		case "allowed-cidrs":
+			req.AllowedCIDRs = client.Null[[]string]()
+		case "denied-cidrs":
+			req.DeniedCIDRs = client.Null[[]string]()
+		case "allowed-referrers":
+			req.AllowedReferrers = client.Null[[]string]()
+		case "no-referrer":
+			req.NoReferrer = client.Null[link.ReferrerPolicy]()
+		case "passthrough":
+			req.Passthrough = client.Null[link.Passthrough]()
+		case "campaign":
+			req.Campaign = client.Null[link.Campaign]()
+		case "redirect-code":
+			req.RedirectCode = client.Null[int]()
+		case "interstitial":
+			req.Interstitial = client.Null[link.Interstitial]()
+		default:
+			return nil, fmt.Errorf("-%s cannot be unset", name)
+		}
+	}
+
+	return req, nil
+}
+
+// list splits a comma-separated flag value, dropping empty items.
+func list(value string) []string {
+	var items []string
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// statuses splits a comma-separated list of link statuses.
+func statuses(value string) []link.Status {
+	var result []link.Status
+	for _, item := range list(value) {
+		result = append(result, link.Status(item))
+	}
+	return result
+}
+
+// pairs parses a comma-separated list of KEY=VALUE pairs.
+func pairs(name, value string) (map[string]string, error) {
+	items := list(value)
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	result := make(map[string]string, len(items))
+	for _, item := range items {
+		key, val, ok := strings.Cut(item, "=")
+		if !ok || key == "" || val == "" {
+			return nil, fmt.Errorf("-%s: %q is not KEY=VALUE", name, item)
+		}
+		result[strings.TrimSpace(key)] = strings.TrimSpace(val)
+	}
+	return result, nil
+}
+
+// optionalTime parses an RFC3339 time, or returns nil for an empty value.
+func optionalTime(name, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("-%s: expected an RFC3339 time such as 2025-07-01T12:00:00Z", name)
+	}
+	return &t, nil
+}
+
+// optionalDuration parses a duration the way the API does (an ISO-8601 or Go
+// duration string, or a number of days), or returns nil for an empty value.
+func optionalDuration(name, value string) (*link.Duration, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	data, _ := json.Marshal(value)
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		data = []byte(value)
+	}
+
+	var d link.Duration
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("-%s: %w", name, err)
+	}
+	return &d, nil
+}
+
+// optionalJSON decodes a JSON flag value into out, leaving it unchanged for
+// an empty value.
+func optionalJSON(name, value string, out any) error {
+	if value == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(value), out); err != nil {
+		return fmt.Errorf("-%s: invalid JSON: %w", name, err)
+	}
+	return nil
+}
+
+// patchValue wraps a parsed optional value as a patch value.
+func patchValue[T any](value *T, err error) (*client.Nullable[T], error) {
+	if err != nil || value == nil {
+		return nil, err
+	}
+	return client.Set(*value), nil
+}
+
+// patchJSON decodes a JSON flag value as a patch value.
+func patchJSON[T any](name, value string) (*client.Nullable[T], error) {
+	var v *T
+	if err := optionalJSON(name, value, &v); err != nil || v == nil {
+		return nil, err
+	}
+	return client.Set(*v), nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// printJSON writes v as indented JSON.
+func printJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// printTable writes the non-empty fields of the struct v as a two-column
+// table, using the JSON field names as labels. Nested values are shown as
+// compact JSON.
+func printTable(w io.Writer, v any) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	val := reflect.Indirect(reflect.ValueOf(v))
+	typ := val.Type()
+	for i := range typ.NumField() {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		field := val.Field(i)
+		if field.IsZero() {
+			continue
+		}
+
+		text, err := formatValue(field.Interface())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", name, text)
+	}
+	return tw.Flush()
+}
+
+// formatValue renders a single table cell.
+func formatValue(v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case time.Time:
+		return v.Local().Format(time.RFC3339), nil
+	case *time.Time:
+		return v.Local().Format(time.RFC3339), nil
+	case fmt.Stringer:
+		return v.String(), nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return strings.Trim(string(data), `"`), nil
+}
+
+// output writes v as JSON or as a table.
+func output(w io.Writer, asJSON bool, v any) error {
+	if asJSON {
+		return printJSON(w, v)
+	}
+	return printTable(w, v)
+}
@@ -0,0 +1,96 @@
+package geoip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"sync"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// DB resolves IP addresses to countries using a local MaxMind-format (.mmdb)
+// database, such as GeoLite2-Country or DB-IP Country Lite. Lookups never
+// leave the machine. The database file can be replaced and reloaded while the
+// server is running.
+type DB struct {
+	path string
+
+	mu     sync.RWMutex
+	reader *maxminddb.Reader
+}
+
+// record is the subset of a country database record used for lookups.
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	RegisteredCountry struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"registered_country"`
+}
+
+// Open opens the database at path.
+func Open(path string) (*DB, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening GeoIP database %s: %w", path, err)
+	}
+	return &DB{path: path, reader: reader}, nil
+}
+
+// NewFromEnv opens the database configured by environment variables, or
+// returns nil if GeoIP lookups are disabled.
+//   - GEOIP_DB: optional path to a MaxMind-format country database
+func NewFromEnv() (*DB, error) {
+	path := os.Getenv("GEOIP_DB")
+	if path == "" {
+		return nil, nil
+	}
+	return Open(path)
+}
+
+// Reload reopens the database file, replacing the current one only if the new
+// file can be opened. In-flight lookups finish against the old database.
+func (db *DB) Reload() error {
+	reader, err := maxminddb.Open(db.path)
+	if err != nil {
+		return fmt.Errorf("error reloading GeoIP database %s: %w", db.path, err)
+	}
+
+	db.mu.Lock()
+	old := db.reader
+	db.reader = reader
+	db.mu.Unlock()
+
+	return old.Close()
+}
+
+// Country returns the ISO 3166-1 alpha-2 code of the country ip is located in,
+// falling back to the country it is registered in, or "" if it is unknown.
+func (db *DB) Country(ip netip.Addr) string {
+	if !ip.IsValid() {
+		return ""
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	var rec record
+	if err := db.reader.Lookup(net.IP(ip.Unmap().AsSlice()), &rec); err != nil {
+		return ""
+	}
+	if rec.Country.ISOCode != "" {
+		return rec.Country.ISOCode
+	}
+	return rec.RegisteredCountry.ISOCode
+}
+
+// Close releases the database.
+func (db *DB) Close(_ context.Context) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.reader.Close()
+}
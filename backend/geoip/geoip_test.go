@@ -0,0 +1,56 @@
+package geoip
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+)
+
+//go:generate sh -c "cd testdata && go run generate.go"
+
+func TestCountry(t *testing.T) {
+	db, err := Open("testdata/country.mmdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close(context.Background()) })
+
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"81.2.69.160", "GB"},
+		{"::ffff:81.2.69.160", "GB"},
+		{"89.160.20.112", "SE"},
+		{"2001:db8:1::1", "JP"},
+		{"1.1.1.1", ""},
+		{"2001:db8:2::1", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := db.Country(netip.MustParseAddr(tt.ip)); got != tt.want {
+				t.Errorf("Country(%s) = %q, want %q", tt.ip, got, tt.want)
+			}
+		})
+	}
+
+	if got := db.Country(netip.Addr{}); got != "" {
+		t.Errorf("Country(invalid) = %q, want \"\"", got)
+	}
+}
+
+func TestReload(t *testing.T) {
+	db, err := Open("testdata/country.mmdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close(context.Background()) })
+
+	if err := db.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if got := db.Country(netip.MustParseAddr("81.2.69.160")); got != "GB" {
+		t.Errorf("Country after reload = %q, want GB", got)
+	}
+}
@@ -0,0 +1,188 @@
+//go:build ignore
+
+// Generate writes country.mmdb, a tiny MaxMind-format country database used by
+// tests. Run it from this directory with:
+//
+//	go run generate.go
+//
+// The database is IPv6 with IPv4 networks in ::/96, 24-bit records, and
+// contains:
+//
+//	81.2.69.0/24     country GB
+//	89.160.20.0/24   registered country SE only
+//	2001:db8:1::/48  country JP
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"log"
+	"maps"
+	"net/netip"
+	"os"
+	"slices"
+	"time"
+)
+
+// Data section types.
+const (
+	typeString = 2
+	typeUint16 = 5
+	typeUint32 = 6
+	typeMap    = 7
+	typeUint64 = 9
+	typeArray  = 11
+)
+
+const recordSize = 24
+
+var networks = []struct {
+	prefix string
+	record map[string]any
+}{
+	{"81.2.69.0/24", map[string]any{"country": map[string]any{"iso_code": "GB"}}},
+	{"89.160.20.0/24", map[string]any{"registered_country": map[string]any{"iso_code": "SE"}}},
+	{"2001:db8:1::/48", map[string]any{"country": map[string]any{"iso_code": "JP"}}},
+}
+
+// node is a search tree node. Each side holds a child node, a data offset, or
+// neither.
+type node struct {
+	children [2]*node
+	data     [2]int
+	index    int
+}
+
+func newNode() *node {
+	return &node{data: [2]int{-1, -1}}
+}
+
+func main() {
+	root := newNode()
+	var data bytes.Buffer
+
+	for _, n := range networks {
+		prefix := netip.MustParsePrefix(n.prefix)
+		bits := prefix.Bits()
+		addr := prefix.Addr()
+		if addr.Is4() {
+			addr = netip.AddrFrom16([16]byte(append(make([]byte, 12), addr.AsSlice()...)))
+			bits += 96
+		}
+
+		offset := data.Len()
+		encode(&data, n.record)
+
+		ip := addr.As16()
+		cur := root
+		for i := 0; i < bits-1; i++ {
+			bit := ip[i/8] >> (7 - i%8) & 1
+			if cur.children[bit] == nil {
+				cur.children[bit] = newNode()
+			}
+			cur = cur.children[bit]
+		}
+		cur.data[ip[(bits-1)/8]>>(7-(bits-1)%8)&1] = offset
+	}
+
+	// Number the nodes breadth first.
+	nodes := []*node{root}
+	for i := 0; i < len(nodes); i++ {
+		nodes[i].index = i
+		for _, child := range nodes[i].children {
+			if child != nil {
+				nodes = append(nodes, child)
+			}
+		}
+	}
+	nodeCount := len(nodes)
+
+	var out bytes.Buffer
+	for _, n := range nodes {
+		for side := range 2 {
+			value := nodeCount // no data
+			switch {
+			case n.children[side] != nil:
+				value = n.children[side].index
+			case n.data[side] >= 0:
+				value = nodeCount + 16 + n.data[side]
+			}
+			out.Write([]byte{byte(value >> 16), byte(value >> 8), byte(value)})
+		}
+	}
+	out.Write(make([]byte, 16))
+	out.Write(data.Bytes())
+
+	out.WriteString("\xab\xcd\xefMaxMind.com")
+	encode(&out, map[string]any{
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).Unix()),
+		"database_type":               "Test-Country",
+		"description":                 map[string]any{"en": "limitlink test database"},
+		"ip_version":                  uint16(6),
+		"languages":                   []any{"en"},
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(recordSize),
+	})
+
+	if err := os.WriteFile("country.mmdb", out.Bytes(), 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// encode appends v to buf in the MaxMind DB data section format.
+func encode(buf *bytes.Buffer, v any) {
+	switch v := v.(type) {
+	case string:
+		control(buf, typeString, len(v))
+		buf.WriteString(v)
+	case uint16:
+		unsigned(buf, typeUint16, uint64(v))
+	case uint32:
+		unsigned(buf, typeUint32, uint64(v))
+	case uint64:
+		unsigned(buf, typeUint64, v)
+	case []any:
+		control(buf, typeArray, len(v))
+		for _, item := range v {
+			encode(buf, item)
+		}
+	case map[string]any:
+		control(buf, typeMap, len(v))
+		for _, key := range sortedKeys(v) {
+			encode(buf, key)
+			encode(buf, v[key])
+		}
+	default:
+		log.Fatalf("unsupported type %T", v)
+	}
+}
+
+// unsigned appends n using the fewest bytes.
+func unsigned(buf *bytes.Buffer, typ int, n uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], n)
+	trimmed := bytes.TrimLeft(b[:], "\x00")
+	control(buf, typ, len(trimmed))
+	buf.Write(trimmed)
+}
+
+// control appends the control byte for a value of typ and size, which must be
+// below 29.
+func control(buf *bytes.Buffer, typ, size int) {
+	if size >= 29 {
+		log.Fatalf("size %d too large", size)
+	}
+	if typ <= 7 {
+		buf.WriteByte(byte(typ<<5 | size))
+		return
+	}
+	buf.WriteByte(byte(size))
+	buf.WriteByte(byte(typ - 7))
+}
+
+// sortedKeys returns the keys of m in order, so the output is reproducible.
+func sortedKeys(m map[string]any) []string {
+	return slices.Sorted(maps.Keys(m))
+}
@@ -0,0 +1,149 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	// defaultDrainDelay is how long to keep serving after being marked
+	// not-ready, giving load balancers time to notice.
+	defaultDrainDelay = 5 * time.Second
+
+	// defaultTimeout bounds the shutdown of servers, flushers, and closers.
+	defaultTimeout = 10 * time.Second
+)
+
+// step is a named shutdown action.
+type step struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Manager stops the application in a safe order:
+//
+//  1. mark the application not-ready
+//  2. wait for the drain delay while still serving requests
+//  3. shut down HTTP servers, waiting for in-flight handlers
+//  4. flush buffered data such as hit counters, events, and traces
+//  5. close stores
+//
+// Steps within each stage run in the order they were registered.
+type Manager struct {
+	drainDelay time.Duration
+	timeout    time.Duration
+
+	notReady []func()
+	servers  []step
+	flushers []step
+	closers  []step
+}
+
+// New returns a Manager with the given drain delay and shutdown timeout.
+func New(drainDelay, timeout time.Duration) *Manager {
+	return &Manager{
+		drainDelay: drainDelay,
+		timeout:    timeout,
+	}
+}
+
+// NewFromEnv returns a Manager configured with environment variables.
+//   - SHUTDOWN_DRAIN_DELAY: Go duration to wait after going not-ready (default 5s)
+//   - SHUTDOWN_TIMEOUT: Go duration bounding the remaining steps (default 10s)
+func NewFromEnv() (*Manager, error) {
+	drainDelay, err := durationFromEnv("SHUTDOWN_DRAIN_DELAY", defaultDrainDelay)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := durationFromEnv("SHUTDOWN_TIMEOUT", defaultTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return New(drainDelay, timeout), nil
+}
+
+// OnNotReady registers a function called as soon as shutdown begins.
+func (m *Manager) OnNotReady(fn func()) {
+	m.notReady = append(m.notReady, fn)
+}
+
+// AddServer registers an HTTP server to be gracefully shut down.
+func (m *Manager) AddServer(name string, srv *http.Server) {
+	m.servers = append(m.servers, step{name, srv.Shutdown})
+}
+
+// AddFlusher registers a function that writes out buffered data. Flushers run
+// after every server has stopped, so no new data can be buffered.
+func (m *Manager) AddFlusher(name string, fn func(ctx context.Context) error) {
+	m.flushers = append(m.flushers, step{name, fn})
+}
+
+// AddCloser registers a function that releases a resource such as a store.
+// Closers run last, after every flusher.
+func (m *Manager) AddCloser(name string, fn func(ctx context.Context) error) {
+	m.closers = append(m.closers, step{name, fn})
+}
+
+// Shutdown runs every registered step in order. A failing step is logged and
+// does not prevent later steps from running.
+// Returns the joined errors of all failed steps.
+func (m *Manager) Shutdown() error {
+	for _, fn := range m.notReady {
+		fn()
+	}
+	slog.Info("marked not ready, draining", slog.Duration("drain_delay", m.drainDelay))
+
+	time.Sleep(m.drainDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	return m.run(ctx)
+}
+
+// Close skips readiness and draining and immediately runs the remaining steps.
+// Use it when the application is stopping because of a failure.
+func (m *Manager) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	return m.run(ctx)
+}
+
+// run executes servers, flushers, and closers in order.
+func (m *Manager) run(ctx context.Context) error {
+	var errs []error
+	for _, stage := range [][]step{m.servers, m.flushers, m.closers} {
+		for _, s := range stage {
+			if err := s.fn(ctx); err != nil {
+				slog.Error("error during shutdown", slog.String("step", s.name), slog.Any("error", err))
+				errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+				continue
+			}
+			slog.Info("shutdown step completed", slog.String("step", s.name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// durationFromEnv parses the named environment variable as a duration,
+// returning def if it is unset.
+func durationFromEnv(name string, def time.Duration) (time.Duration, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid %s: must not be negative", name)
+	}
+	return d, nil
+}
@@ -0,0 +1,136 @@
+package lifecycle_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lucasmcclean/limitlink/lifecycle"
+	"github.com/lucasmcclean/limitlink/server"
+)
+
+func init() {
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+}
+
+// events records the order in which shutdown steps happen.
+type events struct {
+	mu   sync.Mutex
+	list []string
+}
+
+func (e *events) add(event string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.list = append(e.list, event)
+}
+
+func (e *events) get() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.list)
+}
+
+func TestShutdownDrainsInFlightRequests(t *testing.T) {
+	var log events
+	health := server.NewHealth(nil)
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	mux := http.NewServeMux()
+	mux.Handle("/readyz", server.ReadinessHandler(health))
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		log.add("request done")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: mux}
+	go func() { _ = srv.Serve(ln) }()
+	base := "http://" + ln.Addr().String()
+
+	m := lifecycle.New(200*time.Millisecond, 5*time.Second)
+	m.OnNotReady(func() {
+		log.add("not ready")
+		health.SetNotReady()
+	})
+	m.AddServer("http", srv)
+	m.AddFlusher("flush", func(context.Context) error {
+		log.add("flush")
+		return nil
+	})
+	m.AddCloser("close", func(context.Context) error {
+		log.add("close")
+		return nil
+	})
+
+	slow := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(base + "/slow")
+		if err != nil {
+			t.Errorf("slow request: %v", err)
+			slow <- 0
+			return
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		slow <- resp.StatusCode
+	}()
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- m.Shutdown() }()
+
+	// While draining, the server still answers, but reports not ready.
+	deadline := time.Now().Add(time.Second)
+	for {
+		resp, err := http.Get(base + "/readyz")
+		if err != nil {
+			t.Fatalf("readiness check during drain: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusServiceUnavailable {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("readiness = %d, want %d while draining", resp.StatusCode, http.StatusServiceUnavailable)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Let the drain delay pass so the server is waiting on the request.
+	time.Sleep(400 * time.Millisecond)
+	select {
+	case err := <-shutdown:
+		t.Fatalf("shutdown returned before the in-flight request finished: %v", err)
+	default:
+	}
+	close(release)
+
+	if code := <-slow; code < 200 || code > 299 {
+		t.Errorf("in-flight request status = %d, want 2xx", code)
+	}
+	if err := <-shutdown; err != nil {
+		t.Errorf("Shutdown: %v", err)
+	}
+
+	want := []string{"not ready", "request done", "flush", "close"}
+	if got := log.get(); !slices.Equal(got, want) {
+		t.Errorf("shutdown order = %q, want %q", got, want)
+	}
+
+	if _, err := http.Get(base + "/readyz"); err == nil {
+		t.Error("server still accepting connections after shutdown")
+	}
+}
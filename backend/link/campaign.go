@@ -0,0 +1,109 @@
+package link
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// maxCampaignValueLen is the maximum length of a campaign parameter value.
+const maxCampaignValueLen = 100
+
+// slugPlaceholder is replaced with the link's slug in campaign values.
+const slugPlaceholder = "{slug}"
+
+// campaignParams maps UTM query parameters to their campaign fields.
+var campaignParams = []struct {
+	param string
+	field func(*Campaign) *string
+}{
+	{"utm_source", func(c *Campaign) *string { return &c.Source }},
+	{"utm_medium", func(c *Campaign) *string { return &c.Medium }},
+	{"utm_campaign", func(c *Campaign) *string { return &c.Name }},
+	{"utm_term", func(c *Campaign) *string { return &c.Term }},
+	{"utm_content", func(c *Campaign) *string { return &c.Content }},
+}
+
+// Campaign holds UTM parameters added to the target at redirect time.
+// Values may contain "{slug}", which is replaced with the link's slug.
+type Campaign struct {
+	Source  string `bson:"source,omitempty" json:"source,omitempty"`   // utm_source, e.g. "newsletter"
+	Medium  string `bson:"medium,omitempty" json:"medium,omitempty"`   // utm_medium, e.g. "email"
+	Name    string `bson:"name,omitempty" json:"name,omitempty"`       // utm_campaign, e.g. "spring_sale"
+	Term    string `bson:"term,omitempty" json:"term,omitempty"`       // utm_term
+	Content string `bson:"content,omitempty" json:"content,omitempty"` // utm_content
+}
+
+// ParseCampaign parses campaign defaults from a query string of UTM
+// parameters, e.g. "utm_source=limitlink&utm_medium=link".
+func ParseCampaign(query string) (Campaign, error) {
+	var c Campaign
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return c, err
+	}
+
+	for key := range values {
+		found := false
+		for _, p := range campaignParams {
+			if p.param == key {
+				*p.field(&c) = values.Get(key)
+				found = true
+			}
+		}
+		if !found {
+			return c, fmt.Errorf("unknown campaign parameter %q", key)
+		}
+	}
+
+	if err := validateCampaign(&c); err != nil {
+		return c, err
+	}
+	return c, nil
+}
+
+// Tag adds the link's campaign parameters to target, filling in fields the
+// link leaves empty from defaults. Parameters already present on the target
+// are kept, and only the missing ones are appended to the query, so the
+// existing query string is left exactly as written. Links without a campaign
+// are not tagged.
+func (l *Link) Tag(target string, defaults Campaign) (string, error) {
+	if l.Campaign == nil {
+		return target, nil
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", err
+	}
+
+	// Malformed pairs are skipped; every well-formed key still counts as present.
+	present, _ := url.ParseQuery(u.RawQuery)
+	var missing []string
+	for _, p := range campaignParams {
+		value := *p.field(l.Campaign)
+		if value == "" {
+			value = *p.field(&defaults)
+		}
+		if value == "" || present.Has(p.param) {
+			continue
+		}
+		value = strings.ReplaceAll(value, slugPlaceholder, l.Slug)
+		missing = append(missing, p.param+"="+url.QueryEscape(value))
+	}
+	if len(missing) == 0 {
+		return target, nil
+	}
+
+	base, fragment := target, ""
+	if i := strings.IndexByte(target, '#'); i >= 0 {
+		base, fragment = target[:i], target[i:]
+	}
+	switch {
+	case !strings.Contains(base, "?"):
+		base += "?"
+	case !strings.HasSuffix(base, "?") && !strings.HasSuffix(base, "&"):
+		base += "&"
+	}
+	return base + strings.Join(missing, "&") + fragment, nil
+}
@@ -0,0 +1,39 @@
+package link
+
+import "testing"
+
+func TestTag(t *testing.T) {
+	defaults := Campaign{Source: "limitlink", Medium: "link"}
+
+	tests := []struct {
+		name     string
+		campaign *Campaign
+		target   string
+		want     string
+	}{
+		{"no campaign", nil, "https://example.com/?b=2&a=1", "https://example.com/?b=2&a=1"},
+		{"no query", &Campaign{Name: "spring"}, "https://example.com/sale", "https://example.com/sale?utm_source=limitlink&utm_medium=link&utm_campaign=spring"},
+		{"existing query untouched", &Campaign{Name: "spring"}, "https://example.com/?z=1&a=x+y%20z&a=2&flag", "https://example.com/?z=1&a=x+y%20z&a=2&flag&utm_source=limitlink&utm_medium=link&utm_campaign=spring"},
+		{"empty query", &Campaign{Name: "spring"}, "https://example.com/?", "https://example.com/?utm_source=limitlink&utm_medium=link&utm_campaign=spring"},
+		{"trailing ampersand", &Campaign{Name: "spring"}, "https://example.com/?a=1&", "https://example.com/?a=1&utm_source=limitlink&utm_medium=link&utm_campaign=spring"},
+		{"fragment", &Campaign{Name: "spring"}, "https://example.com/?a=1#top", "https://example.com/?a=1&utm_source=limitlink&utm_medium=link&utm_campaign=spring#top"},
+		{"fragment without query", &Campaign{}, "https://example.com/page#a?b", "https://example.com/page?utm_source=limitlink&utm_medium=link#a?b"},
+		{"present parameters kept", &Campaign{Source: "mail", Name: "spring"}, "https://example.com/?utm_source=ads&utm_campaign=", "https://example.com/?utm_source=ads&utm_campaign=&utm_medium=link"},
+		{"escaped key counts as present", &Campaign{}, "https://example.com/?utm%5Fsource=ads", "https://example.com/?utm%5Fsource=ads&utm_medium=link"},
+		{"all present", &Campaign{}, "https://example.com/?utm_medium=x&utm_source=y", "https://example.com/?utm_medium=x&utm_source=y"},
+		{"escaped values and slug", &Campaign{Name: "{slug} & co", Content: "a/b"}, "https://example.com/", "https://example.com/?utm_source=limitlink&utm_medium=link&utm_campaign=abcdef+%26+co&utm_content=a%2Fb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lnk := &Link{Slug: "abcdef", Campaign: tt.campaign}
+			got, err := lnk.Tag(tt.target, defaults)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Tag(%q) =\n%q, want\n%q", tt.target, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package link
+
+import (
+	"errors"
+	"strings"
+)
+
+// errorCodes assigns each error returned by this package a stable,
+// machine-readable code that clients can rely on instead of the message.
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{ErrInvalidURLFormat, "invalid_url_format"},
+	{ErrURLSchemeNotHTTPorHTTPS, "url_scheme_not_http"},
+	{ErrURLMissingHost, "url_missing_host"},
+	{ErrExpiresAtTooSoon, "expires_at_too_soon"},
+	{ErrExpiresAtTooFar, "expires_at_too_far"},
+	{ErrMaxHitsNegative, "max_hits_negative"},
+	{ErrMaxHitsTooLarge, "max_hits_too_large"},
+	{ErrPasswordTooLong, "password_too_long"},
+	{ErrValidFromTooSoon, "valid_from_too_soon"},
+	{ErrValidFromTooFar, "valid_from_too_far"},
+	{ErrAdminExpiresBeforeExpires, "admin_expires_before_expires"},
+	{ErrAdminExpiresRequiresExpires, "admin_expires_requires_expires"},
+	{ErrUpdatedAtNotSet, "updated_at_not_set"},
+	{ErrUnrecognizedCharset, "unrecognized_charset"},
+	{ErrInvalidSlugLen, "invalid_slug_len"},
+	{ErrGeneratingSlug, "generating_slug"},
+	{ErrHashingPassword, "hashing_password"},
+	{ErrConflict, "conflict"},
+	{ErrInvalidDuration, "invalid_duration"},
+	{ErrNegativeDuration, "negative_duration"},
+	{ErrConflictingExpiration, "conflicting_expiration"},
+	{ErrConflictingValidFrom, "conflicting_valid_from"},
+	{ErrValidFromAfterExpiresAt, "valid_from_after_expires_at"},
+	{ErrSelfDestructTooShort, "self_destruct_too_short"},
+	{ErrSelfDestructExceedsExpiry, "self_destruct_exceeds_expiry"},
+	{ErrScheduleMissingTimeZone, "schedule_missing_time_zone"},
+	{ErrScheduleInvalidTimeZone, "schedule_invalid_time_zone"},
+	{ErrScheduleNoWindows, "schedule_no_windows"},
+	{ErrScheduleWindowNoDays, "schedule_window_no_days"},
+	{ErrScheduleInvalidDay, "schedule_invalid_day"},
+	{ErrScheduleInvalidWindow, "schedule_invalid_window"},
+	{ErrScheduleTooManyExcepts, "schedule_too_many_excepts"},
+	{ErrScheduleInvalidExcept, "schedule_invalid_except"},
+	{ErrInvalidFallbackReason, "invalid_fallback_reason"},
+	{ErrFallbackOnWithoutTarget, "fallback_on_without_target"},
+	{ErrTargetAndVariants, "target_and_variants"},
+	{ErrVariantCount, "variant_count"},
+	{ErrInvalidVariantName, "invalid_variant_name"},
+	{ErrDuplicateVariantName, "duplicate_variant_name"},
+	{ErrInvalidVariantWeight, "invalid_variant_weight"},
+	{ErrTooManyRules, "too_many_rules"},
+	{ErrRuleWithoutCondition, "rule_without_condition"},
+	{ErrTooManyRuleValues, "too_many_rule_values"},
+	{ErrInvalidRuleDevice, "invalid_rule_device"},
+	{ErrInvalidRuleLanguage, "invalid_rule_language"},
+	{ErrInvalidRuleReferrer, "invalid_rule_referrer"},
+	{ErrInvalidRuleQuery, "invalid_rule_query"},
+	{ErrAllowedAndBlockedCountries, "allowed_and_blocked_countries"},
+	{ErrTooManyCountries, "too_many_countries"},
+	{ErrInvalidCountry, "invalid_country"},
+	{ErrTooManyCIDRs, "too_many_cidrs"},
+	{ErrInvalidCIDR, "invalid_cidr"},
+	{ErrTooManyReferrers, "too_many_referrers"},
+	{ErrInvalidReferrer, "invalid_referrer"},
+	{ErrInvalidReferrerPolicy, "invalid_referrer_policy"},
+	{ErrNoReferrerWithoutDomains, "no_referrer_without_domains"},
+	{ErrInvalidQueryConflict, "invalid_query_conflict"},
+	{ErrTooManyPassthroughArgs, "too_many_passthrough_args"},
+	{ErrInvalidPassthroughArg, "invalid_passthrough_arg"},
+	{ErrQueryOptionsWithout, "query_options_without"},
+	{ErrQueryConflict, "query_conflict"},
+	{ErrUnsafePathSuffix, "unsafe_path_suffix"},
+	{ErrCampaignValueTooLong, "campaign_value_too_long"},
+	{ErrInvalidCampaignValue, "invalid_campaign_value"},
+	{ErrInvalidTemplate, "invalid_template"},
+	{ErrTemplateInAuthority, "template_in_authority"},
+	{ErrInvalidRedirectCode, "invalid_redirect_code"},
+	{ErrInvalidCountdown, "invalid_countdown"},
+	{ErrNoticeTooLong, "notice_too_long"},
+}
+
+// ErrorCode returns the code of the package error wrapped by err, or "" if err
+// doesn't wrap one.
+func ErrorCode(err error) string {
+	for _, ec := range errorCodes {
+		if errors.Is(err, ec.err) {
+			return ec.code
+		}
+	}
+	return ""
+}
+
+// ErrorForCode returns the package error with the given code, or nil if the
+// code is unknown.
+func ErrorForCode(code string) error {
+	for _, ec := range errorCodes {
+		if ec.code == code {
+			return ec.err
+		}
+	}
+	return nil
+}
+
+// ErrorForMessage returns the package error whose message ends message, as
+// the text of an error wrapping it does, or nil if there is none. It serves
+// clients of servers that report errors without a code.
+func ErrorForMessage(message string) error {
+	for _, ec := range errorCodes {
+		if strings.HasSuffix(message, ec.err.Error()) {
+			return ec.err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package link
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration is a JSON duration relative to the server's current time.
+//
+// It accepts any of:
+//   - an ISO-8601 duration string using weeks, days, hours, minutes, and
+//     seconds (e.g. "P1W", "P1DT12H", "PT90M")
+//   - a Go duration string (e.g. "36h", "90m")
+//   - a JSON number of days (e.g. 7 or 0.5)
+type Duration time.Duration
+
+// MarshalJSON encodes the duration as a Go duration string (e.g. "10m0s").
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON parses a duration from a JSON string or number.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var days float64
+	if err := json.Unmarshal(data, &days); err == nil {
+		if days < 0 {
+			return ErrNegativeDuration
+		}
+		*d = Duration(days * float64(24*time.Hour))
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return ErrInvalidDuration
+	}
+
+	parsed, err := parseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// parseDuration parses an ISO-8601 or Go duration string.
+func parseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, ErrInvalidDuration
+	}
+
+	var d time.Duration
+	var err error
+	if s[0] == 'P' || s[0] == 'p' {
+		d, err = parseISODuration(s)
+	} else {
+		d, err = time.ParseDuration(s)
+	}
+	if err != nil {
+		return 0, ErrInvalidDuration
+	}
+	if d < 0 {
+		return 0, ErrNegativeDuration
+	}
+	return d, nil
+}
+
+// parseISODuration parses the subset of ISO-8601 durations with fixed-length
+// units: PnW, PnD, and PTnHnMnS combinations. Years and months are rejected
+// because their length depends on the calendar.
+func parseISODuration(s string) (time.Duration, error) {
+	s = strings.ToUpper(s[1:])
+	if s == "" || s == "T" {
+		return 0, ErrInvalidDuration
+	}
+
+	var total time.Duration
+	inTime := false
+	for s != "" {
+		if s[0] == 'T' {
+			if inTime {
+				return 0, ErrInvalidDuration
+			}
+			inTime = true
+			s = s[1:]
+			if s == "" {
+				return 0, ErrInvalidDuration
+			}
+			continue
+		}
+
+		i := strings.IndexAny(s, "WDHMS")
+		if i <= 0 {
+			return 0, ErrInvalidDuration
+		}
+		value, err := strconv.ParseFloat(s[:i], 64)
+		if err != nil || value < 0 {
+			return 0, ErrInvalidDuration
+		}
+
+		var unit time.Duration
+		switch {
+		case s[i] == 'W' && !inTime:
+			unit = 7 * 24 * time.Hour
+		case s[i] == 'D' && !inTime:
+			unit = 24 * time.Hour
+		case s[i] == 'H' && inTime:
+			unit = time.Hour
+		case s[i] == 'M' && inTime:
+			unit = time.Minute
+		case s[i] == 'S' && inTime:
+			unit = time.Second
+		default:
+			return 0, ErrInvalidDuration
+		}
+
+		total += time.Duration(value * float64(unit))
+		s = s[i+1:]
+	}
+
+	return total, nil
+}
@@ -0,0 +1,32 @@
+package link
+
+import (
+	"regexp"
+	"slices"
+)
+
+// maxCountries is the maximum number of countries in each country list.
+const maxCountries = 250
+
+// countryPattern matches ISO 3166-1 alpha-2 country codes such as "DE".
+var countryPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// AllowsCountry reports whether visitors from country may use the link.
+// Visitors whose country is unknown ("") are refused when the link has an
+// allow list and admitted when it only has a block list.
+func (l *Link) AllowsCountry(country string) bool {
+	if len(l.AllowedCountries) != 0 {
+		return slices.Contains(l.AllowedCountries, country)
+	}
+	return country == "" || !slices.Contains(l.BlockedCountries, country)
+}
+
+// CountryTarget returns the target overriding the default target for visitors
+// from country, if there is one.
+func (l *Link) CountryTarget(country string) (string, bool) {
+	if country == "" {
+		return "", false
+	}
+	target, ok := l.CountryTargets[country]
+	return target, ok
+}
@@ -0,0 +1,61 @@
+package link
+
+import (
+	"net/url"
+	"slices"
+)
+
+// DestinationHosts returns the distinct hosts the link may redirect to when
+// available, including those of variants, routing rules and country targets.
+func (l *Link) DestinationHosts() []string {
+	targets := []string{l.Target}
+	for _, v := range l.Variants {
+		targets = append(targets, v.Target)
+	}
+	for _, rule := range l.Rules {
+		targets = append(targets, rule.Target)
+	}
+	for _, target := range l.CountryTargets {
+		targets = append(targets, target)
+	}
+
+	var hosts []string
+	for _, target := range targets {
+		if target == "" {
+			continue
+		}
+		parsed, err := url.Parse(placeholderPattern.ReplaceAllString(target, ""))
+		if err != nil || parsed.Host == "" {
+			continue
+		}
+		if !slices.Contains(hosts, parsed.Hostname()) {
+			hosts = append(hosts, parsed.Hostname())
+		}
+	}
+	slices.Sort(hosts)
+	return hosts
+}
+
+// RemainingHits returns the number of hits left before the link is
+// exhausted, or nil if the number of hits is unlimited.
+func (l *Link) RemainingHits() *int {
+	if l.MaxHits == nil {
+		return nil
+	}
+	remaining := max(*l.MaxHits-l.HitCount, 0)
+	return &remaining
+}
+
+// PointsTo reports whether the link may redirect to domain or one of its
+// subdomains, including through its fallback target.
+func (l *Link) PointsTo(domain string) bool {
+	hosts := l.DestinationHosts()
+	if l.FallbackTarget != nil {
+		if parsed, err := url.Parse(placeholderPattern.ReplaceAllString(*l.FallbackTarget, "")); err == nil {
+			hosts = append(hosts, parsed.Hostname())
+		}
+	}
+	return slices.ContainsFunc(hosts, func(host string) bool {
+		return MatchesDomain(host, domain)
+	})
+}
@@ -0,0 +1,17 @@
+package link
+
+const (
+	// maxCountdown is the longest interstitial countdown in seconds.
+	maxCountdown = 60
+
+	// maxNoticeLen is the maximum length of an interstitial notice.
+	maxNoticeLen = 2000
+)
+
+// Interstitial is a warning page shown before redirecting, for example for
+// risky destinations or compliance notices. The hit is counted only once the
+// visitor continues.
+type Interstitial struct {
+	Countdown int    `bson:"countdown,omitempty" json:"countdown,omitempty"` // Seconds before continuing automatically (0: wait for the visitor)
+	Notice    string `bson:"notice,omitempty" json:"notice,omitempty"`       // Optional text shown above the destination
+}
@@ -1,10 +1,12 @@
 package link
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"reflect"
 	"strings"
 	"time"
 
@@ -13,55 +15,122 @@ import (
 
 // rawJSONInput represents the expected structure of JSON input for creating a new link.
 type rawJSONInput struct {
-	Target      string  `json:"target"`              // Required: destination URL
-	SlugLength  int     `json:"slugLength"`          // Required: length of the generated slug
-	SlugCharset string  `json:"slugCharset"`         // Required: allowed characters in the slug
-	ExpiresAt   string  `json:"expiresAt,omitempty"` // Required: RFC3339 absolute expiration
-	Password    *string `json:"password,omitempty"`  // Optional: password to protect the link
-	MaxHits     *int    `json:"maxHits,omitempty"`   // Optional: max allowed hits
-	ValidFrom   *string `json:"validFrom,omitempty"` // Optional: RFC3339 start time for link validity
+	Target      string    `json:"target"`                // Required (or variants): destination URL
+	SlugLength  int       `json:"slugLength"`            // Required: length of the generated slug
+	SlugCharset string    `json:"slugCharset"`           // Required: allowed characters in the slug
+	ExpiresAt   string    `json:"expiresAt,omitempty"`   // Required (or expiresIn/validFor): RFC3339 absolute expiration
+	ExpiresIn   *Duration `json:"expiresIn,omitempty"`   // Required (or expiresAt/validFor): expiration relative to now
+	ValidFor    *Duration `json:"validFor,omitempty"`    // Required (or expiresAt/expiresIn): lifetime from the start time
+	Password    *string   `json:"password,omitempty"`    // Optional: password to protect the link
+	MaxHits     *int      `json:"maxHits,omitempty"`     // Optional: max allowed hits
+	ValidFrom   *string   `json:"validFrom,omitempty"`   // Optional: RFC3339 start time for link validity
+	ValidFromIn *Duration `json:"validFromIn,omitempty"` // Optional: start time relative to now
+
+	SelfDestructAfter *Duration         `json:"selfDestructAfter,omitempty"` // Optional: lifetime counted from the first hit
+	Schedule          *Schedule         `json:"schedule,omitempty"`          // Optional: recurring availability windows
+	FallbackTarget    *string           `json:"fallbackTarget,omitempty"`    // Optional: destination used while unavailable
+	FallbackOn        []Status          `json:"fallbackOn,omitempty"`        // Optional: reasons that use the fallback (default expired, exhausted)
+	Variants          []Variant         `json:"variants,omitempty"`          // Optional: weighted targets used instead of target
+	StickyVariants    bool              `json:"stickyVariants,omitempty"`    // Optional: keep visitors on their first variant
+	Rules             []Rule            `json:"rules,omitempty"`             // Optional: ordered conditional targets
+	AllowedCountries  []string          `json:"allowedCountries,omitempty"`  // Optional: countries allowed to use the link
+	BlockedCountries  []string          `json:"blockedCountries,omitempty"`  // Optional: countries refused access
+	CountryTargets    map[string]string `json:"countryTargets,omitempty"`    // Optional: per-country target overrides
+	AllowedCIDRs      []string          `json:"allowedCidrs,omitempty"`      // Optional: networks allowed to use the link
+	DeniedCIDRs       []string          `json:"deniedCidrs,omitempty"`       // Optional: networks refused access
+	AllowedReferrers  []string          `json:"allowedReferrers,omitempty"`  // Optional: referrer domains the link may be followed from
+	NoReferrer        ReferrerPolicy    `json:"noReferrer,omitempty"`        // Optional: allow or deny requests without a referrer
+	Passthrough       *Passthrough      `json:"passthrough,omitempty"`       // Optional: forwarding of the request path and query
+	Campaign          *Campaign         `json:"campaign,omitempty"`          // Optional: UTM parameters added at redirect time
+	RedirectCode      int               `json:"redirectCode,omitempty"`      // Optional: redirect status code
+	Interstitial      *Interstitial     `json:"interstitial,omitempty"`      // Optional: warning page shown before redirecting
 }
 
 // FromJSON reads, validates, and converts JSON input into a Validated Link.
 //
 // It expects JSON with the following fields:
-//   - Required: target, slugLength, slugCharset
-//   - Required expiration: either expiresAt (RFC3339) or expiresIn (days)
-//   - Optional: password, maxHits, validFrom (RFC3339)
+//   - Required: slugLength, slugCharset, and either target or variants
+//     (weighted targets, each with a name, target, and weight)
+//   - Required expiration: exactly one of expiresAt (RFC3339), expiresIn
+//     (duration from now), or validFor (duration from the start time)
+//   - Optional: password, maxHits, and at most one of validFrom (RFC3339) or
+//     validFromIn (duration from now)
+//   - Optional: selfDestructAfter (duration the link stays usable after its
+//     first hit)
+//   - Optional: schedule (time zone, weekly windows, and exception dates)
+//   - Optional: fallbackTarget (URL) and fallbackOn (expired, exhausted,
+//     not_yet_valid, closed)
+//   - Optional: stickyVariants (keep visitors on their first variant)
+//   - Optional: rules (ordered targets chosen by device, language, referrer,
+//     or query parameters before falling back to the default target)
+//   - Optional: at most one of allowedCountries or blockedCountries, and
+//     countryTargets (ISO 3166-1 alpha-2 codes, e.g. "DE", mapped to URLs)
+//   - Optional: allowedCidrs and deniedCidrs (networks such as 10.0.0.0/8 or
+//     single IP addresses)
+//   - Optional: allowedReferrers (domains, including their subdomains) and
+//     noReferrer (allow or deny requests without a Referer header)
+//   - Optional: passthrough (forward the path after the slug and/or merge the
+//     incoming query, with a conflict policy and parameter allowlist; the
+//     path /slug/qr is reserved for the QR code and never forwarded)
+//   - Optional: campaign (source, medium, name, term, and content UTM
+//     parameters; empty fields use the server defaults)
+//   - Optional: redirectCode (302, 303, or 307; permanent codes are refused
+//     because every link expires and a cached redirect would outlive it)
+//   - Optional: interstitial (warning page with a countdown and notice)
+//
+// Durations are resolved against now, so clients with skewed clocks can use
+// them safely. See Duration for the accepted formats.
 //
 // Returns a validated link or an error.
-func FromJSON(r io.Reader, now time.Time) (*Validated, error) {
+func FromJSON(ctx context.Context, r io.Reader, now time.Time) (*Validated, error) {
 	var input rawJSONInput
 	if err := json.NewDecoder(r).Decode(&input); err != nil {
 		return nil, fmt.Errorf("invalid JSON: %w", err)
 	}
 
 	missing := make([]string, 0, 4)
-	if input.Target == "" {
+	if input.Target == "" && len(input.Variants) == 0 {
 		missing = append(missing, "target")
 	}
 	if input.SlugCharset == "" {
 		missing = append(missing, "slugCharset")
 	}
-	if input.ExpiresAt == "" {
+	if input.ExpiresAt == "" && input.ExpiresIn == nil && input.ValidFor == nil {
 		missing = append(missing, "expiresAt")
 	}
 	if len(missing) != 0 {
 		return nil, errors.New("missing one or more required fields: " + strings.Join(missing, ", "))
 	}
 
-	expiresAt, err := time.Parse(time.RFC3339, input.ExpiresAt)
-	if err != nil {
-		return nil, fmt.Errorf("invalid expiresAt: %w", err)
-	}
-
 	var validFrom *time.Time
 	if input.ValidFrom != nil && *input.ValidFrom != "" {
+		if input.ValidFromIn != nil {
+			return nil, ErrConflictingValidFrom
+		}
 		vf, err := time.Parse(time.RFC3339, *input.ValidFrom)
 		if err != nil {
 			return nil, fmt.Errorf("invalid validFrom: %w", err)
 		}
 		validFrom = &vf
+	} else if input.ValidFromIn != nil {
+		vf := now.Add(time.Duration(*input.ValidFromIn))
+		validFrom = &vf
+	}
+
+	var expiresAt time.Time
+	switch {
+	case countSet(input.ExpiresAt != "", input.ExpiresIn != nil, input.ValidFor != nil) > 1:
+		return nil, ErrConflictingExpiration
+	case input.ExpiresAt != "":
+		ea, err := time.Parse(time.RFC3339, input.ExpiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expiresAt: %w", err)
+		}
+		expiresAt = ea
+	case input.ExpiresIn != nil:
+		expiresAt = now.Add(time.Duration(*input.ExpiresIn))
+	default:
+		expiresAt = startOrNow(validFrom, now).Add(time.Duration(*input.ValidFor))
 	}
 
 	maxHits := input.MaxHits
@@ -69,19 +138,37 @@ func FromJSON(r io.Reader, now time.Time) (*Validated, error) {
 	adminExpiresAt := expiresAt.Add(24 * time.Hour)
 
 	link := &Link{
-		ID:             primitive.NewObjectID(),
-		Slug:           "",
-		AdminToken:     "",
-		Target:         input.Target,
-		PasswordHash:   nil,
-		MaxHits:        maxHits,
-		ValidFrom:      validFrom,
-		CreatedAt:      now,
-		UpdatedAt:      now,
-		ExpiresAt:      expiresAt,
-		AdminExpiresAt: adminExpiresAt,
-		HitCount:       0,
-		SchemaVersion:  schemaVersion,
+		ID:                primitive.NewObjectID(),
+		Slug:              "",
+		AdminToken:        "",
+		Target:            input.Target,
+		PasswordHash:      nil,
+		MaxHits:           maxHits,
+		ValidFrom:         validFrom,
+		SelfDestructAfter: input.SelfDestructAfter,
+		Schedule:          input.Schedule,
+		FallbackTarget:    input.FallbackTarget,
+		FallbackOn:        input.FallbackOn,
+		Variants:          withHitCounts(input.Variants, nil),
+		StickyVariants:    input.StickyVariants,
+		Rules:             input.Rules,
+		AllowedCountries:  input.AllowedCountries,
+		BlockedCountries:  input.BlockedCountries,
+		CountryTargets:    input.CountryTargets,
+		AllowedCIDRs:      input.AllowedCIDRs,
+		DeniedCIDRs:       input.DeniedCIDRs,
+		AllowedReferrers:  input.AllowedReferrers,
+		NoReferrer:        input.NoReferrer,
+		Passthrough:       input.Passthrough,
+		Campaign:          input.Campaign,
+		RedirectCode:      input.RedirectCode,
+		Interstitial:      input.Interstitial,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		ExpiresAt:         expiresAt,
+		AdminExpiresAt:    adminExpiresAt,
+		HitCount:          0,
+		SchemaVersion:     CurrentSchemaVersion,
 	}
 
 	validated, err := Validate(link, now)
@@ -89,7 +176,7 @@ func FromJSON(r io.Reader, now time.Time) (*Validated, error) {
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
-	err = validated.SetPasswordHash(input.Password)
+	err = validated.SetPasswordHash(ctx, input.Password)
 	if err != nil {
 		return nil, fmt.Errorf("error generating the hash: %w", err)
 	}
@@ -114,49 +201,236 @@ func FromJSON(r io.Reader, now time.Time) (*Validated, error) {
 //   - Explicit nulls: *T == nil
 //   - Set values: **T
 type rawJSONPatch struct {
-	ExpiresAt **time.Time `json:"expiresAt"`
-	MaxHits   **int       `json:"maxHits"`
-	ValidFrom **time.Time `json:"validFrom"`
-	Password  **string    `json:"password"`
+	Target      **string    `json:"target"`
+	ExpiresAt   **time.Time `json:"expiresAt"`
+	ExpiresIn   **Duration  `json:"expiresIn"`
+	ValidFor    **Duration  `json:"validFor"`
+	MaxHits     **int       `json:"maxHits"`
+	ValidFrom   **time.Time `json:"validFrom"`
+	ValidFromIn **Duration  `json:"validFromIn"`
+	Password    **string    `json:"password"`
+
+	SelfDestructAfter **Duration          `json:"selfDestructAfter"`
+	Schedule          **Schedule          `json:"schedule"`
+	FallbackTarget    **string            `json:"fallbackTarget"`
+	FallbackOn        **[]Status          `json:"fallbackOn"`
+	Variants          **[]Variant         `json:"variants"`
+	StickyVariants    **bool              `json:"stickyVariants"`
+	Rules             **[]Rule            `json:"rules"`
+	AllowedCountries  **[]string          `json:"allowedCountries"`
+	BlockedCountries  **[]string          `json:"blockedCountries"`
+	CountryTargets    **map[string]string `json:"countryTargets"`
+	AllowedCIDRs      **[]string          `json:"allowedCidrs"`
+	DeniedCIDRs       **[]string          `json:"deniedCidrs"`
+	AllowedReferrers  **[]string          `json:"allowedReferrers"`
+	NoReferrer        **ReferrerPolicy    `json:"noReferrer"`
+	Passthrough       **Passthrough       `json:"passthrough"`
+	Campaign          **Campaign          `json:"campaign"`
+	RedirectCode      **int               `json:"redirectCode"`
+	Interstitial      **Interstitial      `json:"interstitial"`
 }
 
 // PatchFromJSON applies partial JSON updates to a Link.
 //
 // Accepts a JSON payload with any combination of:
-//   - expiresAt: null (remove) or timestamp (update)
+//   - target: URL (update, removing any variants)
+//   - variants: null (remove) or list of weighted targets (replace, clearing
+//     target and keeping hit counts of variants with the same name)
+//   - stickyVariants: boolean (update)
+//   - rules: null (remove) or list of rules (replace)
+//   - allowedCountries, blockedCountries: null (remove) or list of country
+//     codes (replace)
+//   - countryTargets: null (remove) or map of country codes to URLs (replace)
+//   - allowedCidrs, deniedCidrs: null (remove) or list of networks (replace)
+//   - allowedReferrers: null (remove) or list of domains (replace)
+//   - noReferrer: null (reset to server default) or allow/deny (update)
+//   - passthrough: null (remove) or passthrough options (replace)
+//   - campaign: null (remove) or campaign parameters (replace)
+//   - redirectCode: null (reset to server default) or status code (update)
+//   - interstitial: null (remove) or warning page options (replace)
+//   - expiresAt: timestamp (update)
+//   - expiresIn: duration from now (update expiresAt)
+//   - validFor: duration from the start time (update expiresAt)
 //   - maxHits: null (remove) or integer (update)
 //   - validFrom: null (remove) or timestamp (update)
+//   - validFromIn: null (remove) or duration from now (update validFrom)
 //   - password: null (remove) or string (update)
+//   - selfDestructAfter: null (remove) or duration (update)
+//   - schedule: null (remove) or schedule (replace)
+//   - fallbackTarget: null (remove) or URL (update)
+//   - fallbackOn: null (reset to default) or list of reasons (replace)
 //
+// At most one of expiresAt, expiresIn, and validFor and at most one of
+// validFrom and validFromIn may be provided. validFor is measured from the
+// patched start time, the original start time, or now, in that order.
 // Fields not provided in the JSON will not be changed.
 // Returns a validated patch or an error.
-func PatchFromJSON(data []byte, original *Link) (*ValidatedPatch, error) {
+func PatchFromJSON(ctx context.Context, data []byte, original *Link) (*ValidatedPatch, error) {
 	var raw rawJSONPatch
 	if err := json.Unmarshal(data, &raw); err != nil {
 		return nil, fmt.Errorf("invalid JSON: %w", err)
 	}
+	if err := markNulls(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
 
 	now := time.Now()
 	patch := NewPatchLink(now)
 
-	if raw.ExpiresAt != nil {
-		if *raw.ExpiresAt == nil {
-			return nil, errors.New("expiresAt cannot be null")
+	if raw.Target != nil {
+		if *raw.Target == nil {
+			return nil, errors.New("target cannot be null")
 		}
-		patch.ExpiresAt = *raw.ExpiresAt
+		patch.Target = *raw.Target
+	}
 
-		adminExpiresAt := (*raw.ExpiresAt).Add(24 * time.Hour)
-		patch.AdminExpiresAt = &adminExpiresAt
+	if raw.SelfDestructAfter != nil {
+		if *raw.SelfDestructAfter == nil {
+			patch.SelfDestructAfter.Remove = true
+		} else {
+			patch.SelfDestructAfter.Value = *raw.SelfDestructAfter
+		}
 	}
 
-	if raw.MaxHits != nil {
-		if *raw.MaxHits == nil {
-			patch.MaxHits.Remove = true
+	if raw.Schedule != nil {
+		if *raw.Schedule == nil {
+			patch.Schedule.Remove = true
 		} else {
-			patch.MaxHits.Value = *raw.MaxHits
+			patch.Schedule.Value = *raw.Schedule
+		}
+	}
+
+	if raw.Variants != nil {
+		if *raw.Variants == nil {
+			patch.Variants.Remove = true
+		} else {
+			patch.Variants.Value = *raw.Variants
+		}
+	}
+
+	if raw.StickyVariants != nil {
+		if *raw.StickyVariants == nil {
+			return nil, errors.New("stickyVariants cannot be null")
+		}
+		patch.StickyVariants = *raw.StickyVariants
+	}
+
+	if raw.Rules != nil {
+		if *raw.Rules == nil {
+			patch.Rules.Remove = true
+		} else {
+			patch.Rules.Value = *raw.Rules
+		}
+	}
+
+	if raw.AllowedCountries != nil {
+		if *raw.AllowedCountries == nil {
+			patch.AllowedCountries.Remove = true
+		} else {
+			patch.AllowedCountries.Value = *raw.AllowedCountries
+		}
+	}
+
+	if raw.BlockedCountries != nil {
+		if *raw.BlockedCountries == nil {
+			patch.BlockedCountries.Remove = true
+		} else {
+			patch.BlockedCountries.Value = *raw.BlockedCountries
+		}
+	}
+
+	if raw.CountryTargets != nil {
+		if *raw.CountryTargets == nil {
+			patch.CountryTargets.Remove = true
+		} else {
+			patch.CountryTargets.Value = *raw.CountryTargets
+		}
+	}
+
+	if raw.AllowedCIDRs != nil {
+		if *raw.AllowedCIDRs == nil {
+			patch.AllowedCIDRs.Remove = true
+		} else {
+			patch.AllowedCIDRs.Value = *raw.AllowedCIDRs
+		}
+	}
+
+	if raw.DeniedCIDRs != nil {
+		if *raw.DeniedCIDRs == nil {
+			patch.DeniedCIDRs.Remove = true
+		} else {
+			patch.DeniedCIDRs.Value = *raw.DeniedCIDRs
+		}
+	}
+
+	if raw.AllowedReferrers != nil {
+		if *raw.AllowedReferrers == nil {
+			patch.AllowedReferrers.Remove = true
+		} else {
+			patch.AllowedReferrers.Value = *raw.AllowedReferrers
+		}
+	}
+
+	if raw.NoReferrer != nil {
+		if *raw.NoReferrer == nil {
+			patch.NoReferrer.Remove = true
+		} else {
+			patch.NoReferrer.Value = *raw.NoReferrer
+		}
+	}
+
+	if raw.Passthrough != nil {
+		if *raw.Passthrough == nil {
+			patch.Passthrough.Remove = true
+		} else {
+			patch.Passthrough.Value = *raw.Passthrough
+		}
+	}
+
+	if raw.Campaign != nil {
+		if *raw.Campaign == nil {
+			patch.Campaign.Remove = true
+		} else {
+			patch.Campaign.Value = *raw.Campaign
+		}
+	}
+
+	if raw.RedirectCode != nil {
+		if *raw.RedirectCode == nil {
+			patch.RedirectCode.Remove = true
+		} else {
+			patch.RedirectCode.Value = *raw.RedirectCode
+		}
+	}
+
+	if raw.Interstitial != nil {
+		if *raw.Interstitial == nil {
+			patch.Interstitial.Remove = true
+		} else {
+			patch.Interstitial.Value = *raw.Interstitial
+		}
+	}
+
+	if raw.FallbackTarget != nil {
+		if *raw.FallbackTarget == nil {
+			patch.FallbackTarget.Remove = true
+		} else {
+			patch.FallbackTarget.Value = *raw.FallbackTarget
 		}
 	}
 
+	if raw.FallbackOn != nil {
+		if *raw.FallbackOn == nil {
+			patch.FallbackOn.Remove = true
+		} else {
+			patch.FallbackOn.Value = *raw.FallbackOn
+		}
+	}
+
+	if raw.ValidFrom != nil && raw.ValidFromIn != nil {
+		return nil, ErrConflictingValidFrom
+	}
+
 	if raw.ValidFrom != nil {
 		if *raw.ValidFrom == nil {
 			patch.ValidFrom.Remove = true
@@ -165,6 +439,61 @@ func PatchFromJSON(data []byte, original *Link) (*ValidatedPatch, error) {
 		}
 	}
 
+	if raw.ValidFromIn != nil {
+		if *raw.ValidFromIn == nil {
+			patch.ValidFrom.Remove = true
+		} else {
+			validFrom := now.Add(time.Duration(**raw.ValidFromIn))
+			patch.ValidFrom.Value = &validFrom
+		}
+	}
+
+	if countSet(raw.ExpiresAt != nil, raw.ExpiresIn != nil, raw.ValidFor != nil) > 1 {
+		return nil, ErrConflictingExpiration
+	}
+
+	var expiresAt *time.Time
+	switch {
+	case raw.ExpiresAt != nil:
+		if *raw.ExpiresAt == nil {
+			return nil, errors.New("expiresAt cannot be null")
+		}
+		expiresAt = *raw.ExpiresAt
+	case raw.ExpiresIn != nil:
+		if *raw.ExpiresIn == nil {
+			return nil, errors.New("expiresIn cannot be null")
+		}
+		ea := now.Add(time.Duration(**raw.ExpiresIn))
+		expiresAt = &ea
+	case raw.ValidFor != nil:
+		if *raw.ValidFor == nil {
+			return nil, errors.New("validFor cannot be null")
+		}
+		start := original.ValidFrom
+		if patch.ValidFrom.Remove {
+			start = nil
+		} else if patch.ValidFrom.Value != nil {
+			start = patch.ValidFrom.Value
+		}
+		ea := startOrNow(start, now).Add(time.Duration(**raw.ValidFor))
+		expiresAt = &ea
+	}
+
+	if expiresAt != nil {
+		patch.ExpiresAt = expiresAt
+
+		adminExpiresAt := expiresAt.Add(24 * time.Hour)
+		patch.AdminExpiresAt = &adminExpiresAt
+	}
+
+	if raw.MaxHits != nil {
+		if *raw.MaxHits == nil {
+			patch.MaxHits.Remove = true
+		} else {
+			patch.MaxHits.Value = *raw.MaxHits
+		}
+	}
+
 	validated, err := ValidatePatch(original, patch, now)
 	if err != nil {
 		return nil, err
@@ -174,7 +503,7 @@ func PatchFromJSON(data []byte, original *Link) (*ValidatedPatch, error) {
 		if *raw.Password == nil {
 			patch.PasswordHash.Remove = true
 		} else {
-			err = validated.SetPasswordHash(*raw.Password)
+			err = validated.SetPasswordHash(ctx, *raw.Password)
 			if err != nil {
 				return nil, ErrHashingPassword
 			}
@@ -183,3 +512,54 @@ func PatchFromJSON(data []byte, original *Link) (*ValidatedPatch, error) {
 
 	return validated, nil
 }
+
+// countSet returns how many of the given conditions are true.
+func countSet(set ...bool) int {
+	n := 0
+	for _, s := range set {
+		if s {
+			n++
+		}
+	}
+	return n
+}
+
+// startOrNow returns the start time if set, or now otherwise.
+func startOrNow(start *time.Time, now time.Time) time.Time {
+	if start != nil {
+		return *start
+	}
+	return now
+}
+
+// markNulls records the fields of raw that data sets to null. encoding/json
+// stores null by setting a pointer field to nil, which is indistinguishable
+// from an omitted field, so each such **T field is set to a pointer to a nil
+// *T instead.
+//
+// data is decoded a second time into a struct of json.RawMessage fields with
+// the same tags, so keys are matched to fields exactly as encoding/json
+// matched them, case-insensitively included.
+func markNulls(data []byte, raw *rawJSONPatch) error {
+	v := reflect.ValueOf(raw).Elem()
+	fields := make([]reflect.StructField, v.NumField())
+	for i := range fields {
+		f := v.Type().Field(i)
+		fields[i] = reflect.StructField{Name: f.Name, Type: reflect.TypeFor[json.RawMessage](), Tag: f.Tag}
+	}
+	values := reflect.New(reflect.StructOf(fields))
+	if err := json.Unmarshal(data, values.Interface()); err != nil {
+		return err
+	}
+
+	for i := range fields {
+		value := values.Elem().Field(i).Interface().(json.RawMessage)
+		if string(value) != "null" {
+			continue
+		}
+		if field := v.Field(i); field.Kind() == reflect.Pointer && field.Type().Elem().Kind() == reflect.Pointer {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package link
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFromJSONTimes(t *testing.T) {
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+
+	tests := []struct {
+		name          string
+		fields        string
+		wantExpiresAt *time.Time
+		wantValidFrom *time.Time
+		wantErr       error // nil with a nil wantExpiresAt means any error
+	}{
+		{"absolute expiration", `"expiresAt":"2025-07-02T12:00:00Z"`, at(24 * time.Hour), nil, nil},
+		{"iso expiresIn", `"expiresIn":"P1DT12H"`, at(36 * time.Hour), nil, nil},
+		{"go expiresIn", `"expiresIn":"90m"`, at(90 * time.Minute), nil, nil},
+		{"days expiresIn", `"expiresIn":0.5`, at(12 * time.Hour), nil, nil},
+		{"validFor from now", `"validFor":"PT2H"`, at(2 * time.Hour), nil, nil},
+		{"absolute start, relative lifetime", `"validFrom":"2025-07-01T13:00:00Z","validFor":"PT2H"`, at(3 * time.Hour), at(time.Hour), nil},
+		{"relative start, relative lifetime", `"validFromIn":"PT1H","validFor":"P1D"`, at(25 * time.Hour), at(time.Hour), nil},
+		{"relative start, absolute expiration", `"validFromIn":"1h","expiresAt":"2025-07-03T12:00:00Z"`, at(48 * time.Hour), at(time.Hour), nil},
+		{"absolute start, relative expiration", `"validFrom":"2025-07-01T13:00:00Z","expiresIn":"P2D"`, at(48 * time.Hour), at(time.Hour), nil},
+		{"lifetime ignores start-relative expiration", `"validFromIn":"P1D","expiresIn":"P2D"`, at(48 * time.Hour), at(24 * time.Hour), nil},
+
+		{"missing expiration", `"validFromIn":"PT1H"`, nil, nil, nil},
+		{"expiresAt and expiresIn", `"expiresAt":"2025-07-02T12:00:00Z","expiresIn":"P1D"`, nil, nil, ErrConflictingExpiration},
+		{"expiresAt and validFor", `"expiresAt":"2025-07-02T12:00:00Z","validFor":"P1D"`, nil, nil, ErrConflictingExpiration},
+		{"expiresIn and validFor", `"expiresIn":"P1D","validFor":"P1D"`, nil, nil, ErrConflictingExpiration},
+		{"validFrom and validFromIn", `"validFrom":"2025-07-01T13:00:00Z","validFromIn":"PT1H","expiresIn":"P1D"`, nil, nil, ErrConflictingValidFrom},
+		{"invalid timestamp", `"expiresAt":"tomorrow"`, nil, nil, nil},
+		{"months are calendar dependent", `"expiresIn":"P1M"`, nil, nil, ErrInvalidDuration},
+		{"empty duration", `"expiresIn":""`, nil, nil, ErrInvalidDuration},
+		{"negative go duration", `"expiresIn":"-1h"`, nil, nil, ErrNegativeDuration},
+		{"negative days", `"validFor":-1`, nil, nil, ErrNegativeDuration},
+		{"relative expiration too soon", `"expiresIn":"PT30S"`, nil, nil, ErrExpiresAtTooSoon},
+		{"relative expiration too far", `"expiresIn":"P31D"`, nil, nil, ErrExpiresAtTooFar},
+		{"relative start too soon", `"validFromIn":"PT10S","expiresIn":"P1D"`, nil, nil, ErrValidFromTooSoon},
+		{"relative start after absolute expiration", `"validFromIn":"P2D","expiresAt":"2025-07-02T12:00:00Z"`, nil, nil, ErrValidFromAfterExpiresAt},
+		{"absolute start after relative expiration", `"validFrom":"2025-07-03T12:00:00Z","expiresIn":"P1D"`, nil, nil, ErrValidFromAfterExpiresAt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"target":"https://example.com","slugLength":8,"slugCharset":"letters",` + tt.fields + `}`
+			validated, err := FromJSON(context.Background(), strings.NewReader(body), now)
+
+			if tt.wantExpiresAt == nil {
+				if err == nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			lnk := validated.Link()
+			if !lnk.ExpiresAt.Equal(*tt.wantExpiresAt) {
+				t.Errorf("ExpiresAt = %v, want %v", lnk.ExpiresAt, tt.wantExpiresAt)
+			}
+			switch {
+			case tt.wantValidFrom == nil && lnk.ValidFrom != nil:
+				t.Errorf("ValidFrom = %v, want none", lnk.ValidFrom)
+			case tt.wantValidFrom != nil && (lnk.ValidFrom == nil || !lnk.ValidFrom.Equal(*tt.wantValidFrom)):
+				t.Errorf("ValidFrom = %v, want %v", lnk.ValidFrom, tt.wantValidFrom)
+			}
+		})
+	}
+}
+
+func TestPatchFromJSONTimes(t *testing.T) {
+	type offset = *time.Duration
+	in := func(d time.Duration) offset { return &d }
+
+	tests := []struct {
+		name          string
+		validFrom     offset // start of the original link, from now
+		body          string
+		wantExpiresIn offset // nil means unchanged
+		wantValidFrom offset // nil means none
+		wantErr       bool
+		err           error
+	}{
+		{"relative expiration", nil, `{"expiresIn":"PT2H"}`, in(2 * time.Hour), nil, false, nil},
+		{"lifetime from now", nil, `{"validFor":"PT2H"}`, in(2 * time.Hour), nil, false, nil},
+		{"lifetime from original start", in(time.Hour), `{"validFor":"PT2H"}`, in(3 * time.Hour), in(time.Hour), false, nil},
+		{"lifetime from patched relative start", in(time.Hour), `{"validFromIn":"PT5H","validFor":"PT2H"}`, in(7 * time.Hour), in(5 * time.Hour), false, nil},
+		{"lifetime after removing start", in(time.Hour), `{"validFrom":null,"validFor":"PT2H"}`, in(2 * time.Hour), nil, false, nil},
+		{"relative start, unchanged expiration", nil, `{"validFromIn":"PT1H"}`, nil, in(time.Hour), false, nil},
+		{"removing relative start", in(time.Hour), `{"validFromIn":null}`, nil, nil, false, nil},
+
+		{"expiresAt and validFor", nil, `{"expiresAt":"2030-01-01T00:00:00Z","validFor":"P1D"}`, nil, nil, true, ErrConflictingExpiration},
+		{"expiresIn and expiresAt", nil, `{"expiresIn":"P1D","expiresAt":"2030-01-01T00:00:00Z"}`, nil, nil, true, ErrConflictingExpiration},
+		{"validFrom and validFromIn", nil, `{"validFrom":null,"validFromIn":"PT1H"}`, nil, nil, true, ErrConflictingValidFrom},
+		{"null expiresIn", nil, `{"expiresIn":null}`, nil, nil, true, nil},
+		{"null validFor", nil, `{"validFor":null}`, nil, nil, true, nil},
+		{"invalid duration", nil, `{"expiresIn":"P1Y"}`, nil, nil, true, ErrInvalidDuration},
+		{"relative start after expiration", nil, `{"validFromIn":"P2D"}`, nil, nil, true, ErrValidFromAfterExpiresAt},
+		{"relative expiration before start", in(2 * time.Hour), `{"expiresIn":"PT1H"}`, nil, nil, true, ErrValidFromAfterExpiresAt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := time.Now()
+			original := &Link{
+				Target:         "https://example.com",
+				CreatedAt:      before,
+				ExpiresAt:      before.Add(24 * time.Hour),
+				AdminExpiresAt: before.Add(48 * time.Hour),
+			}
+			if tt.validFrom != nil {
+				vf := before.Add(*tt.validFrom)
+				original.ValidFrom = &vf
+			}
+
+			validated, err := PatchFromJSON(context.Background(), []byte(tt.body), original)
+			after := time.Now()
+
+			if tt.wantErr {
+				if err == nil || tt.err != nil && !errors.Is(err, tt.err) {
+					t.Fatalf("err = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Relative times resolve against the patch's own clock reading, so
+			// they fall between before and after, while times taken from the
+			// original link are exact.
+			within := func(got time.Time, d time.Duration) bool {
+				return !got.Before(before.Add(d)) && !got.After(after.Add(d))
+			}
+
+			patch := validated.Patch()
+			switch {
+			case tt.wantExpiresIn == nil && patch.ExpiresAt != nil:
+				t.Errorf("ExpiresAt = %v, want unchanged", patch.ExpiresAt)
+			case tt.wantExpiresIn != nil && (patch.ExpiresAt == nil || !within(*patch.ExpiresAt, *tt.wantExpiresIn)):
+				t.Errorf("ExpiresAt = %v, want now + %v", patch.ExpiresAt, *tt.wantExpiresIn)
+			}
+
+			validFrom := original.ValidFrom
+			if patch.ValidFrom.Remove {
+				validFrom = nil
+			} else if patch.ValidFrom.Value != nil {
+				validFrom = patch.ValidFrom.Value
+			}
+			switch {
+			case tt.wantValidFrom == nil && validFrom != nil:
+				t.Errorf("ValidFrom = %v, want none", validFrom)
+			case tt.wantValidFrom != nil && (validFrom == nil || !within(*validFrom, *tt.wantValidFrom)):
+				t.Errorf("ValidFrom = %v, want now + %v", validFrom, *tt.wantValidFrom)
+			}
+		})
+	}
+}
+
+func TestPatchFromJSONNulls(t *testing.T) {
+	now := time.Now()
+	maxHits := 10
+	password := "hash"
+	original := &Link{
+		Target:         "https://example.com",
+		MaxHits:        &maxHits,
+		PasswordHash:   &password,
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(time.Hour),
+		AdminExpiresAt: now.Add(2 * time.Hour),
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		removeMaxHits  bool
+		removePassword bool
+	}{
+		{"omitted", `{}`, false, false},
+		{"set", `{"maxHits":5}`, false, false},
+		{"null", `{"maxHits":null}`, true, false},
+		{"null with spaces", `{"maxHits": null }`, true, false},
+		{"null in another case", `{"MaxHits":null}`, true, false},
+		{"several nulls", `{"maxHits":null,"PASSWORD":null}`, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validated, err := PatchFromJSON(context.Background(), []byte(tt.body), original)
+			if err != nil {
+				t.Fatalf("PatchFromJSON: %v", err)
+			}
+			patch := validated.Patch()
+			if patch.MaxHits.Remove != tt.removeMaxHits {
+				t.Errorf("MaxHits.Remove = %v, want %v", patch.MaxHits.Remove, tt.removeMaxHits)
+			}
+			if patch.PasswordHash.Remove != tt.removePassword {
+				t.Errorf("PasswordHash.Remove = %v, want %v", patch.PasswordHash.Remove, tt.removePassword)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package link
 
 import (
+	"slices"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -28,37 +29,155 @@ const (
 	// maxTime is the maximum amount of time in the future a provided time can be.
 	maxTime = time.Hour * 24 * 30
 
-	// schemaVersion defines the current version of the link schema.
-	schemaVersion = 1
+	// MaxRevisions is the number of past revisions kept for each link.
+	MaxRevisions = 20
+
+	// CurrentSchemaVersion defines the current version of the link schema.
+	//   - 1: slug, admin token, target, hit count and max hits, password,
+	//     start time, and creation, update and expiration timestamps
+	//   - 2: adds the revision number, self-destruct timer and first hit time,
+	//     schedule, fallback target and its hit count, variants, rules,
+	//     country, network and referrer restrictions, country targets,
+	//     passthrough, campaign, redirect code, interstitial, and disabled time
+	//
+	// Every field added in version 2 is optional, so version 1 documents
+	// still decode and behave as links without those features.
+	CurrentSchemaVersion = 2
+
+	// OldestSchemaVersion is the oldest schema version that can still be read.
+	OldestSchemaVersion = 1
 )
 
 // Link represents a shortened URL with optional access controls and usage
 // limits.
 type Link struct {
-	ID             primitive.ObjectID `bson:"_id,omitempty" json:"-"`                          // MongoDB ID
-	Slug           string             `bson:"slug" json:"slug"`                                // Unique identifier for the link
-	AdminToken     string             `bson:"admin_token" json:"adminToken"`                   // Owner’s admin token
-	Target         string             `bson:"target" json:"target"`                            // Destination URL
-	HitCount       int                `bson:"hit_count" json:"hitCount"`                       // Number of hits so far
-	MaxHits        *int               `bson:"max_hits,omitempty" json:"maxHits,omitempty"`     // Optional max allowed hits
-	PasswordHash   *string            `bson:"password_hash,omitempty" json:"-"`                // Optional password hash (not exposed in JSON)
-	ValidFrom      *time.Time         `bson:"valid_from,omitempty" json:"validFrom,omitempty"` // Optional start validity timestamp
-	CreatedAt      time.Time          `bson:"created_at" json:"createdAt"`                     // Creation timestamp
-	ExpiresAt      time.Time          `bson:"expires_at" json:"expiresAt"`                     // Expiration timestamp
-	AdminExpiresAt time.Time          `bson:"admin_expires_at" json:"adminExpiresAt"`          // Expiration timestamp for admin access
-	UpdatedAt      time.Time          `bson:"updated_at" json:"updatedAt"`                     // Last updated timestamp
-	SchemaVersion  int                `bson:"schema_version" json:"-"`                         // Schema version for migration
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"-"`                                           // MongoDB ID
+	Slug              string             `bson:"slug" json:"slug"`                                                 // Unique identifier for the link
+	AdminToken        string             `bson:"admin_token" json:"adminToken"`                                    // Owner’s admin token
+	Target            string             `bson:"target" json:"target"`                                             // Destination URL
+	HitCount          int                `bson:"hit_count" json:"hitCount"`                                        // Number of hits so far
+	MaxHits           *int               `bson:"max_hits,omitempty" json:"maxHits,omitempty"`                      // Optional max allowed hits
+	PasswordHash      *string            `bson:"password_hash,omitempty" json:"-"`                                 // Optional password hash (not exposed in JSON)
+	ValidFrom         *time.Time         `bson:"valid_from,omitempty" json:"validFrom,omitempty"`                  // Optional start validity timestamp
+	CreatedAt         time.Time          `bson:"created_at" json:"createdAt"`                                      // Creation timestamp
+	ExpiresAt         time.Time          `bson:"expires_at" json:"expiresAt"`                                      // Expiration timestamp
+	AdminExpiresAt    time.Time          `bson:"admin_expires_at" json:"adminExpiresAt"`                           // Expiration timestamp for admin access
+	UpdatedAt         time.Time          `bson:"updated_at" json:"updatedAt"`                                      // Last updated timestamp
+	SelfDestructAfter *Duration          `bson:"self_destruct_after,omitempty" json:"selfDestructAfter,omitempty"` // Optional lifetime counted from the first hit
+	FirstHitAt        *time.Time         `bson:"first_hit_at,omitempty" json:"firstHitAt,omitempty"`               // Time of the first successful redirect
+	Schedule          *Schedule          `bson:"schedule,omitempty" json:"schedule,omitempty"`                     // Optional recurring availability windows
+	FallbackTarget    *string            `bson:"fallback_target,omitempty" json:"fallbackTarget,omitempty"`        // Optional destination used while unavailable
+	FallbackOn        []Status           `bson:"fallback_on,omitempty" json:"fallbackOn,omitempty"`                // Unavailability reasons that use the fallback
+	FallbackHitCount  int                `bson:"fallback_hit_count" json:"fallbackHitCount"`                       // Number of fallback redirects so far
+	Variants          []Variant          `bson:"variants,omitempty" json:"variants,omitempty"`                     // Optional weighted targets used instead of Target
+	StickyVariants    bool               `bson:"sticky_variants,omitempty" json:"stickyVariants,omitempty"`        // Whether visitors keep their first variant
+	Rules             []Rule             `bson:"rules,omitempty" json:"rules,omitempty"`                           // Ordered conditional targets tried before the default
+	AllowedCountries  []string           `bson:"allowed_countries,omitempty" json:"allowedCountries,omitempty"`    // Countries (ISO 3166-1 alpha-2) allowed to use the link
+	BlockedCountries  []string           `bson:"blocked_countries,omitempty" json:"blockedCountries,omitempty"`    // Countries refused access to the link
+	CountryTargets    map[string]string  `bson:"country_targets,omitempty" json:"countryTargets,omitempty"`        // Per-country targets overriding the default
+	AllowedCIDRs      []string           `bson:"allowed_cidrs,omitempty" json:"allowedCidrs,omitempty"`            // Networks allowed to use the link
+	DeniedCIDRs       []string           `bson:"denied_cidrs,omitempty" json:"deniedCidrs,omitempty"`              // Networks refused access to the link
+	AllowedReferrers  []string           `bson:"allowed_referrers,omitempty" json:"allowedReferrers,omitempty"`    // Referrer domains the link may be followed from
+	NoReferrer        ReferrerPolicy     `bson:"no_referrer,omitempty" json:"noReferrer,omitempty"`                // Policy for requests without a referrer
+	Passthrough       *Passthrough       `bson:"passthrough,omitempty" json:"passthrough,omitempty"`               // Optional forwarding of the request path and query
+	Campaign          *Campaign          `bson:"campaign,omitempty" json:"campaign,omitempty"`                     // Optional UTM parameters added to the target
+	RedirectCode      int                `bson:"redirect_code,omitempty" json:"redirectCode,omitempty"`            // Optional redirect status code (default: server default)
+	Interstitial      *Interstitial      `bson:"interstitial,omitempty" json:"interstitial,omitempty"`             // Optional warning page shown before redirecting
+	Revision          int                `bson:"revision" json:"revision"`                                         // Number of patches applied so far
+	DisabledAt        *time.Time         `bson:"disabled_at,omitempty" json:"disabledAt,omitempty"`                // Set when an operator takes the link down
+	SchemaVersion     int                `bson:"schema_version" json:"-"`                                          // Schema version for migration
 }
 
-func (l *Link) IsAvailable(now time.Time) bool {
+// Status describes whether a link can currently be followed and, if not, why.
+type Status string
+
+const (
+	StatusAvailable   Status = "available"     // The link can be followed
+	StatusNotYetValid Status = "not_yet_valid" // ValidFrom is still in the future
+	StatusExpired     Status = "expired"       // ExpiresAt has passed
+	StatusExhausted   Status = "exhausted"     // MaxHits has been reached
+	StatusClosed      Status = "closed"        // Outside of every Schedule window
+	StatusDisabled    Status = "disabled"      // Taken down by an operator
+)
+
+// Status reports the availability of the link at the given time.
+func (l *Link) Status(now time.Time) Status {
+	if l.DisabledAt != nil {
+		return StatusDisabled
+	}
 	if l.MaxHits != nil && l.HitCount >= *l.MaxHits {
-		return false
+		return StatusExhausted
 	}
 	if l.ValidFrom != nil && now.Before(*l.ValidFrom) {
-		return false
+		return StatusNotYetValid
+	}
+	if now.After(l.EffectiveExpiresAt()) {
+		return StatusExpired
+	}
+	if l.Schedule != nil && !l.Schedule.IsOpen(now) {
+		return StatusClosed
+	}
+	return StatusAvailable
+}
+
+// defaultFallbackOn lists the reasons that use the fallback target when the
+// owner has not chosen any.
+var defaultFallbackOn = []Status{StatusExpired, StatusExhausted}
+
+// FallbackFor returns the fallback target to use for a link that is
+// unavailable because of status, if the link has one configured for it.
+func (l *Link) FallbackFor(status Status) (string, bool) {
+	if l.FallbackTarget == nil || status == StatusAvailable {
+		return "", false
+	}
+	reasons := l.FallbackOn
+	if len(reasons) == 0 {
+		reasons = defaultFallbackOn
 	}
-	if now.After(l.ExpiresAt) {
-		return false
+	if !slices.Contains(reasons, status) {
+		return "", false
 	}
-	return true
+	return *l.FallbackTarget, true
+}
+
+// NextOpening returns the earliest time at or after now when the link's
+// start time and schedule allow it to be followed, or nil if that will not
+// happen before it expires. Hit limits are not considered.
+func (l *Link) NextOpening(now time.Time) *time.Time {
+	from := now
+	if l.ValidFrom != nil && l.ValidFrom.After(now) {
+		from = *l.ValidFrom
+	}
+	expiresAt := l.EffectiveExpiresAt()
+	if from.After(expiresAt) {
+		return nil
+	}
+	if l.Schedule == nil {
+		return &from
+	}
+	return l.Schedule.NextOpening(from, expiresAt)
+}
+
+// SelfDestructsAt returns when the self-destruct timer runs out, or nil if the
+// link has no timer or has not been hit yet.
+func (l *Link) SelfDestructsAt() *time.Time {
+	if l.SelfDestructAfter == nil || l.FirstHitAt == nil {
+		return nil
+	}
+	at := l.FirstHitAt.Add(time.Duration(*l.SelfDestructAfter))
+	return &at
+}
+
+// EffectiveExpiresAt returns the earlier of ExpiresAt and the time the
+// self-destruct timer runs out.
+func (l *Link) EffectiveExpiresAt() time.Time {
+	if at := l.SelfDestructsAt(); at != nil && at.Before(l.ExpiresAt) {
+		return *at
+	}
+	return l.ExpiresAt
+}
+
+// IsAvailable reports whether the link can be followed at the given time.
+func (l *Link) IsAvailable(now time.Time) bool {
+	return l.Status(now) == StatusAvailable
 }
@@ -0,0 +1,259 @@
+// Package linktest provides an in-memory link.Repository for tests.
+package linktest
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/lucasmcclean/limitlink/link"
+)
+
+// Repository is an in-memory link.Repository. It is safe for concurrent use.
+type Repository struct {
+	mu        sync.Mutex
+	links     map[string]*link.Link // By slug
+	revisions map[string][]link.Revision
+}
+
+// NewRepository returns an empty Repository.
+func NewRepository() *Repository {
+	return &Repository{
+		links:     make(map[string]*link.Link),
+		revisions: make(map[string][]link.Revision),
+	}
+}
+
+// Add stores lnk as is, bypassing validation.
+func (r *Repository) Add(lnk *link.Link) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *lnk
+	r.links[lnk.Slug] = &stored
+}
+
+// Create inserts a new link.
+func (r *Repository) Create(_ context.Context, vLink *link.Validated) error {
+	r.Add(vLink.Link())
+	return nil
+}
+
+// GetBySlug returns a copy of the link with the given slug, or nil.
+func (r *Repository) GetBySlug(_ context.Context, slug string) (*link.Link, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if lnk, ok := r.links[slug]; ok {
+		found := *lnk
+		return &found, nil
+	}
+	return nil, nil
+}
+
+// IncBySlug increments the hit count of the link with the given slug.
+func (r *Repository) IncBySlug(_ context.Context, slug string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if lnk, ok := r.links[slug]; ok {
+		lnk.HitCount++
+		firstHit(lnk)
+	}
+	return nil
+}
+
+// IncVariantBySlug increments the hit counts of the link and its variant.
+func (r *Repository) IncVariantBySlug(_ context.Context, slug, variant string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lnk, ok := r.links[slug]
+	if !ok {
+		return nil
+	}
+	lnk.Variants = slices.Clone(lnk.Variants)
+	if v := lnk.VariantByName(variant); v != nil {
+		v.HitCount++
+	}
+	lnk.HitCount++
+	firstHit(lnk)
+	return nil
+}
+
+// IncFallbackBySlug increments the fallback hit count of the link.
+func (r *Repository) IncFallbackBySlug(_ context.Context, slug string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if lnk, ok := r.links[slug]; ok {
+		lnk.FallbackHitCount++
+	}
+	return nil
+}
+
+// GetByToken returns a copy of the link with the given admin token, or nil.
+func (r *Repository) GetByToken(_ context.Context, token string) (*link.Link, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if lnk := r.byToken(token); lnk != nil {
+		found := *lnk
+		return &found, nil
+	}
+	return nil, nil
+}
+
+// DeleteByToken removes the link with the given admin token.
+func (r *Repository) DeleteByToken(_ context.Context, token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if lnk := r.byToken(token); lnk != nil {
+		delete(r.links, lnk.Slug)
+		delete(r.revisions, lnk.Slug)
+	}
+	return nil
+}
+
+// PatchByToken applies the patch and records the replaced revision. Returns
+// link.ErrConflict if the link changed since the patch was validated.
+func (r *Repository) PatchByToken(_ context.Context, token string, vPatch *link.ValidatedPatch) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	patch := vPatch.Patch()
+	lnk := r.byToken(token)
+	if lnk == nil || lnk.Revision != patch.Previous.Number {
+		return link.ErrConflict
+	}
+
+	updated := *lnk
+	apply(&updated, patch)
+	updated.Revision++
+	r.links[lnk.Slug] = &updated
+
+	revisions := append(r.revisions[lnk.Slug], *patch.Previous)
+	if len(revisions) > link.MaxRevisions {
+		revisions = revisions[len(revisions)-link.MaxRevisions:]
+	}
+	r.revisions[lnk.Slug] = revisions
+	return nil
+}
+
+// ListRevisionsByToken returns the recorded revisions of a link, newest first.
+func (r *Repository) ListRevisionsByToken(_ context.Context, token string) ([]link.Revision, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lnk := r.byToken(token)
+	if lnk == nil {
+		return nil, nil
+	}
+
+	revisions := slices.Clone(r.revisions[lnk.Slug])
+	slices.Reverse(revisions)
+	for i := range revisions {
+		revisions[i].HasPassword = revisions[i].PasswordHash != nil
+	}
+	return revisions, nil
+}
+
+// GetRevisionByToken returns a single revision of a link, or nil.
+func (r *Repository) GetRevisionByToken(ctx context.Context, token string, number int) (*link.Revision, error) {
+	revisions, err := r.ListRevisionsByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	for _, rev := range revisions {
+		if rev.Number == number {
+			return &rev, nil
+		}
+	}
+	return nil, nil
+}
+
+// Ping always succeeds. It implements link.Pinger.
+func (r *Repository) Ping(context.Context) error {
+	return nil
+}
+
+// byToken returns the stored link with the given admin token. The caller must
+// hold r.mu.
+func (r *Repository) byToken(token string) *link.Link {
+	for _, lnk := range r.links {
+		if lnk.AdminToken == token {
+			return lnk
+		}
+	}
+	return nil
+}
+
+// firstHit records the time of the first hit if it is not already set.
+func firstHit(lnk *link.Link) {
+	if lnk.FirstHitAt == nil {
+		now := time.Now()
+		lnk.FirstHitAt = &now
+	}
+}
+
+// apply updates lnk with the fields set in patch.
+func apply(lnk *link.Link, patch *link.PatchLink) {
+	lnk.UpdatedAt = patch.UpdatedAt
+	if patch.Target != nil {
+		lnk.Target = *patch.Target
+	}
+	if patch.ExpiresAt != nil {
+		lnk.ExpiresAt = *patch.ExpiresAt
+	}
+	if patch.AdminExpiresAt != nil {
+		lnk.AdminExpiresAt = *patch.AdminExpiresAt
+	}
+	if patch.StickyVariants != nil {
+		lnk.StickyVariants = *patch.StickyVariants
+	}
+
+	setPointer(&lnk.MaxHits, patch.MaxHits)
+	setPointer(&lnk.PasswordHash, patch.PasswordHash)
+	setPointer(&lnk.ValidFrom, patch.ValidFrom)
+	setPointer(&lnk.Schedule, patch.Schedule)
+	setPointer(&lnk.FallbackTarget, patch.FallbackTarget)
+	setPointer(&lnk.Passthrough, patch.Passthrough)
+	setPointer(&lnk.Campaign, patch.Campaign)
+	setPointer(&lnk.Interstitial, patch.Interstitial)
+	setPointer(&lnk.SelfDestructAfter, patch.SelfDestructAfter)
+
+	setValue(&lnk.FallbackOn, patch.FallbackOn)
+	setValue(&lnk.Variants, patch.Variants)
+	setValue(&lnk.Rules, patch.Rules)
+	setValue(&lnk.AllowedCountries, patch.AllowedCountries)
+	setValue(&lnk.BlockedCountries, patch.BlockedCountries)
+	setValue(&lnk.CountryTargets, patch.CountryTargets)
+	setValue(&lnk.AllowedCIDRs, patch.AllowedCIDRs)
+	setValue(&lnk.DeniedCIDRs, patch.DeniedCIDRs)
+	setValue(&lnk.AllowedReferrers, patch.AllowedReferrers)
+	setValue(&lnk.NoReferrer, patch.NoReferrer)
+	setValue(&lnk.RedirectCode, patch.RedirectCode)
+}
+
+// setPointer applies a patch field to an optional link field.
+func setPointer[T any](dst **T, field link.Field[T]) {
+	switch {
+	case field.Remove:
+		*dst = nil
+	case field.Value != nil:
+		v := *field.Value
+		*dst = &v
+	}
+}
+
+// setValue applies a patch field to a link field whose zero value means unset.
+func setValue[T any](dst *T, field link.Field[T]) {
+	switch {
+	case field.Remove:
+		var zero T
+		*dst = zero
+	case field.Value != nil:
+		*dst = *field.Value
+	}
+}
+
+// Len returns the number of stored links.
+func (r *Repository) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.links)
+}
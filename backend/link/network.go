@@ -0,0 +1,64 @@
+package link
+
+import (
+	"net/netip"
+)
+
+// maxCIDRs is the maximum number of networks in each network list.
+const maxCIDRs = 100
+
+// AllowsIP reports whether a client with the given IP address may use the
+// link. Denied networks take precedence over allowed ones, and clients whose
+// address is unknown are refused when the link has an allow list.
+func (l *Link) AllowsIP(ip netip.Addr) bool {
+	if len(l.AllowedCIDRs) == 0 && len(l.DeniedCIDRs) == 0 {
+		return true
+	}
+	if !ip.IsValid() {
+		return len(l.AllowedCIDRs) == 0
+	}
+
+	ip = ip.Unmap()
+	if containsIP(l.DeniedCIDRs, ip) {
+		return false
+	}
+	return len(l.AllowedCIDRs) == 0 || containsIP(l.AllowedCIDRs, ip)
+}
+
+// containsIP reports whether any of the networks contains ip.
+func containsIP(cidrs []string, ip netip.Addr) bool {
+	for _, cidr := range cidrs {
+		prefix, err := parseCIDR(cidr)
+		if err == nil && prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCIDR parses a network in CIDR notation. A bare IP address is treated as
+// a network containing only that address.
+func parseCIDR(s string) (netip.Prefix, error) {
+	if prefix, err := netip.ParsePrefix(s); err == nil {
+		return prefix.Masked(), nil
+	}
+	ip, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	ip = ip.Unmap()
+	return netip.PrefixFrom(ip, ip.BitLen()), nil
+}
+
+// ParseCIDRs parses a list of networks in CIDR notation or bare IP addresses.
+func ParseCIDRs(list []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(list))
+	for _, s := range list {
+		prefix, err := parseCIDR(s)
+		if err != nil {
+			return nil, ErrInvalidCIDR
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes, nil
+}
@@ -0,0 +1,126 @@
+package link
+
+import (
+	"errors"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// maxPassthroughParams is the maximum number of allowed query parameters.
+const maxPassthroughParams = 50
+
+// QueryConflict decides which value wins when an incoming query parameter is
+// already set on the target.
+type QueryConflict string
+
+// Conflict policies for passed-through query parameters.
+const (
+	KeepTarget   QueryConflict = "target"
+	KeepIncoming QueryConflict = "incoming"
+	RejectQuery  QueryConflict = "reject"
+)
+
+var (
+	// ErrQueryConflict is returned when an incoming query parameter conflicts
+	// with the target under the reject policy.
+	ErrQueryConflict = errors.New("query parameter conflicts with the link target")
+
+	// ErrUnsafePathSuffix is returned when an incoming path suffix contains
+	// segments that could escape the target's path.
+	ErrUnsafePathSuffix = errors.New("path must not contain '.' or '..' segments")
+)
+
+// Passthrough controls which parts of the incoming request are forwarded to
+// the target.
+//
+// The path /slug/qr is reserved for the QR code of the link and is never
+// forwarded. Longer paths starting with qr, such as /slug/qr/ or /slug/qr/x,
+// are forwarded as usual.
+type Passthrough struct {
+	Path          bool          `bson:"path,omitempty" json:"path,omitempty"`                    // Append the path after the slug to the target's path
+	Query         bool          `bson:"query,omitempty" json:"query,omitempty"`                  // Merge incoming query parameters into the target's
+	OnConflict    QueryConflict `bson:"on_conflict,omitempty" json:"onConflict,omitempty"`       // Policy for parameters set on both (default: target)
+	AllowedParams []string      `bson:"allowed_params,omitempty" json:"allowedParams,omitempty"` // Parameters that may pass through (default: all)
+}
+
+// Forward applies the link's passthrough options to target, appending the
+// path segments following the slug and merging the incoming query.
+//
+// Only the path and query of target are ever changed, so its scheme and host
+// can't be redirected elsewhere by the request.
+func (l *Link) Forward(target string, suffix []string, query url.Values) (string, error) {
+	p := l.Passthrough
+	if p == nil || (!p.Path || len(suffix) == 0) && (!p.Query || len(query) == 0) {
+		return target, nil
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", err
+	}
+
+	if p.Path && len(suffix) != 0 {
+		for _, segment := range suffix {
+			if segment == "." || segment == ".." {
+				return "", ErrUnsafePathSuffix
+			}
+		}
+		trailing := suffix[len(suffix)-1] == ""
+		u = u.JoinPath(suffix...)
+		if trailing && !strings.HasSuffix(u.Path, "/") {
+			u.Path += "/"
+			u.RawPath = ""
+		}
+	}
+
+	if p.Query && len(query) != 0 {
+		// Malformed pairs are skipped; every well-formed key still counts as present.
+		present, _ := url.ParseQuery(u.RawQuery)
+		var replaced, pairs []string
+		for _, key := range slices.Sorted(maps.Keys(query)) {
+			if len(p.AllowedParams) != 0 && !slices.Contains(p.AllowedParams, key) {
+				continue
+			}
+			if present.Has(key) {
+				switch p.OnConflict {
+				case KeepIncoming:
+					replaced = append(replaced, key)
+				case RejectQuery:
+					return "", ErrQueryConflict
+				default:
+					continue
+				}
+			}
+			for _, value := range query[key] {
+				pairs = append(pairs, url.QueryEscape(key)+"="+url.QueryEscape(value))
+			}
+		}
+		u.RawQuery = appendQuery(u.RawQuery, replaced, pairs)
+	}
+
+	return u.String(), nil
+}
+
+// appendQuery appends the encoded pairs to the raw query after removing the
+// pairs whose key is in drop. Every other pair of raw is kept exactly as
+// written, so the target's own query is never re-encoded or reordered.
+func appendQuery(raw string, drop, pairs []string) string {
+	if len(drop) != 0 {
+		kept := strings.Split(raw, "&")
+		kept = slices.DeleteFunc(kept, func(pair string) bool {
+			key, _, _ := strings.Cut(pair, "=")
+			key, err := url.QueryUnescape(key)
+			return err == nil && slices.Contains(drop, key)
+		})
+		raw = strings.Join(kept, "&")
+	}
+	if len(pairs) == 0 {
+		return raw
+	}
+	if raw != "" && !strings.HasSuffix(raw, "&") {
+		raw += "&"
+	}
+	return raw + strings.Join(pairs, "&")
+}
@@ -0,0 +1,53 @@
+package link
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestForward(t *testing.T) {
+	query := Passthrough{Query: true}
+	incoming := Passthrough{Query: true, OnConflict: KeepIncoming}
+
+	tests := []struct {
+		name        string
+		passthrough Passthrough
+		target      string
+		suffix      []string
+		query       string
+		want        string
+		wantErr     error
+	}{
+		{"no passthrough", Passthrough{}, "https://example.com/?b=1&a=%7E", []string{"x"}, "c=3", "https://example.com/?b=1&a=%7E", nil},
+		{"target query untouched", query, "https://example.com/?b=1&a=%7E", nil, "c=3", "https://example.com/?b=1&a=%7E&c=3", nil},
+		{"malformed pairs kept", query, "https://example.com/?b=1&%zz&a=%7E;x", nil, "c=3", "https://example.com/?b=1&%zz&a=%7E;x&c=3", nil},
+		{"no target query", query, "https://example.com/", nil, "c=3&a=x+y", "https://example.com/?a=x+y&c=3", nil},
+		{"trailing ampersand", query, "https://example.com/?b=1&", nil, "c=3", "https://example.com/?b=1&c=3", nil},
+		{"fragment", query, "https://example.com/?b=1#top", nil, "c=3", "https://example.com/?b=1&c=3#top", nil},
+		{"conflict keeps target", query, "https://example.com/?b=1&a=%7E", nil, "a=9&c=3", "https://example.com/?b=1&a=%7E&c=3", nil},
+		{"conflict keeps incoming", incoming, "https://example.com/?b=1&a=%7E&a=2&c=%7E", nil, "a=9", "https://example.com/?b=1&c=%7E&a=9", nil},
+		{"escaped key conflicts", incoming, "https://example.com/?%61=1&b=%7E", nil, "a=9", "https://example.com/?b=%7E&a=9", nil},
+		{"conflict rejected", Passthrough{Query: true, OnConflict: RejectQuery}, "https://example.com/?a=1", nil, "a=9", "", ErrQueryConflict},
+		{"allowed params", Passthrough{Query: true, AllowedParams: []string{"c"}}, "https://example.com/?b=1&a=%7E", nil, "c=3&d=4", "https://example.com/?b=1&a=%7E&c=3", nil},
+		{"path and query", Passthrough{Path: true, Query: true}, "https://example.com/docs?b=1&a=%7E", []string{"x", ""}, "c=3", "https://example.com/docs/x/?b=1&a=%7E&c=3", nil},
+		{"unsafe path", Passthrough{Path: true}, "https://example.com/docs", []string{".."}, "", "", ErrUnsafePathSuffix},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lnk := &Link{Passthrough: &tt.passthrough}
+			got, err := lnk.Forward(tt.target, tt.suffix, query)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Forward(%q) err = %v, want %v", tt.target, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Forward(%q) =\n%q, want\n%q", tt.target, got, tt.want)
+			}
+		})
+	}
+}
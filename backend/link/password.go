@@ -1,17 +1,28 @@
 package link
 
 import (
+	"context"
 	"fmt"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
 	"golang.org/x/crypto/bcrypt"
 )
 
 const cost = bcrypt.DefaultCost
 
+var tracer = otel.Tracer("github.com/lucasmcclean/limitlink/link")
+
 // hashPassword hashes a plaintext password.
 // Returns the resulting hash or an error if hashing fails.
-func hashPassword(password string) (string, error) {
+func hashPassword(ctx context.Context, password string) (string, error) {
+	_, span := tracer.Start(ctx, "link.hashPassword")
+	defer span.End()
+
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
 	return string(bytes), err
 }
 
@@ -20,10 +31,14 @@ func hashPassword(password string) (string, error) {
 // Returns true if the password is correct or if there is no password and false
 // otherwise.
 // Returns an error only if there was an unexpected problem verifying the hash.
-func (l *Link) IsCorrectPassword(password string) (bool, error) {
+func (l *Link) IsCorrectPassword(ctx context.Context, password string) (bool, error) {
 	if l.PasswordHash == nil {
 		return true, nil
 	}
+
+	_, span := tracer.Start(ctx, "link.IsCorrectPassword")
+	defer span.End()
+
 	err := bcrypt.CompareHashAndPassword([]byte(*l.PasswordHash), []byte(password))
 	switch err {
 	case bcrypt.ErrMismatchedHashAndPassword:
@@ -31,6 +46,7 @@ func (l *Link) IsCorrectPassword(password string) (bool, error) {
 	case nil:
 		return true, nil
 	default:
+		span.SetStatus(codes.Error, err.Error())
 		return false, fmt.Errorf("error comparing password and hash: %w", err)
 	}
 }
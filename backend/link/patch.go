@@ -13,12 +13,32 @@ type Field[T any] struct {
 // PatchLink represents a partial update to an existing Link.
 // Use the Field type to signal if a field should be updated or explicitly removed.
 type PatchLink struct {
-	MaxHits        Field[int]       `bson:"-"`                          // Optional: set or remove max hit count
-	PasswordHash   Field[string]    `bson:"-"`                          // Optional: set or remove password hash
-	ValidFrom      Field[time.Time] `bson:"-"`                          // Optional: set or remove start time
-	ExpiresAt      *time.Time       `bson:"expires_at,omitempty"`       // New expiration timestamp (or nil to skip)
-	AdminExpiresAt *time.Time       `bson:"admin_expires_at,omitempty"` // New expiration timestamp (or nil to skip)
-	UpdatedAt      time.Time        `bson:"updated_at"`                 // Always updated timestamp
+	Target            *string                  `bson:"target,omitempty"`           // New destination URL (or nil to skip)
+	MaxHits           Field[int]               `bson:"-"`                          // Optional: set or remove max hit count
+	PasswordHash      Field[string]            `bson:"-"`                          // Optional: set or remove password hash
+	ValidFrom         Field[time.Time]         `bson:"-"`                          // Optional: set or remove start time
+	Schedule          Field[Schedule]          `bson:"-"`                          // Optional: set or remove availability schedule
+	FallbackTarget    Field[string]            `bson:"-"`                          // Optional: set or remove fallback target
+	FallbackOn        Field[[]Status]          `bson:"-"`                          // Optional: set or reset fallback reasons
+	Variants          Field[[]Variant]         `bson:"-"`                          // Optional: replace or remove weighted targets
+	StickyVariants    *bool                    `bson:"sticky_variants,omitempty"`  // New variant stickiness (or nil to skip)
+	Rules             Field[[]Rule]            `bson:"-"`                          // Optional: replace or remove routing rules
+	AllowedCountries  Field[[]string]          `bson:"-"`                          // Optional: replace or remove allowed countries
+	BlockedCountries  Field[[]string]          `bson:"-"`                          // Optional: replace or remove blocked countries
+	CountryTargets    Field[map[string]string] `bson:"-"`                          // Optional: replace or remove per-country targets
+	AllowedCIDRs      Field[[]string]          `bson:"-"`                          // Optional: replace or remove allowed networks
+	DeniedCIDRs       Field[[]string]          `bson:"-"`                          // Optional: replace or remove denied networks
+	AllowedReferrers  Field[[]string]          `bson:"-"`                          // Optional: replace or remove allowed referrer domains
+	NoReferrer        Field[ReferrerPolicy]    `bson:"-"`                          // Optional: set or reset the missing-referrer policy
+	Passthrough       Field[Passthrough]       `bson:"-"`                          // Optional: set or remove path and query forwarding
+	Campaign          Field[Campaign]          `bson:"-"`                          // Optional: set or remove UTM campaign parameters
+	RedirectCode      Field[int]               `bson:"-"`                          // Optional: set or reset the redirect status code
+	Interstitial      Field[Interstitial]      `bson:"-"`                          // Optional: set or remove the warning page
+	SelfDestructAfter Field[Duration]          `bson:"-"`                          // Optional: set or remove self-destruct timer
+	ExpiresAt         *time.Time               `bson:"expires_at,omitempty"`       // New expiration timestamp (or nil to skip)
+	AdminExpiresAt    *time.Time               `bson:"admin_expires_at,omitempty"` // New expiration timestamp (or nil to skip)
+	UpdatedAt         time.Time                `bson:"updated_at"`                 // Always updated timestamp
+	Previous          *Revision                `bson:"-"`                          // Snapshot of the link before the patch, set by ValidatePatch
 }
 
 // NewPatchLink initializes a PatchLink with the current time as UpdatedAt.
@@ -4,29 +4,75 @@ import "time"
 
 // PublicLink is a safe-to-share representation of a Link.
 type PublicLink struct {
-	Slug           string     `bson:"slug" json:"slug"`                                // Unique identifier for the link
-	AdminToken     string     `bson:"admin_token" json:"adminToken"`                   // Owner’s admin token
-	Target         string     `bson:"target" json:"target"`                            // Destination URL
-	HitCount       int        `bson:"hit_count" json:"hitCount"`                       // Number of hits so far
-	MaxHits        *int       `bson:"max_hits,omitempty" json:"maxHits,omitempty"`     // Optional max allowed hits
-	ValidFrom      *time.Time `bson:"valid_from,omitempty" json:"validFrom,omitempty"` // Optional start validity timestamp
-	CreatedAt      time.Time  `bson:"created_at" json:"createdAt"`                     // Creation timestamp
-	ExpiresAt      time.Time  `bson:"expires_at" json:"expiresAt"`                     // Expiration timestamp
-	AdminExpiresAt time.Time  `bson:"admin_expires_at" json:"adminExpiresAt"`          // Expiration timestamp for admin access
-	UpdatedAt      time.Time  `bson:"updated_at" json:"updatedAt"`                     // Last updated timestamp
+	Slug              string            `bson:"slug" json:"slug"`                                                 // Unique identifier for the link
+	AdminToken        string            `bson:"admin_token" json:"adminToken"`                                    // Owner’s admin token
+	Target            string            `bson:"target" json:"target"`                                             // Destination URL
+	HitCount          int               `bson:"hit_count" json:"hitCount"`                                        // Number of hits so far
+	MaxHits           *int              `bson:"max_hits,omitempty" json:"maxHits,omitempty"`                      // Optional max allowed hits
+	ValidFrom         *time.Time        `bson:"valid_from,omitempty" json:"validFrom,omitempty"`                  // Optional start validity timestamp
+	CreatedAt         time.Time         `bson:"created_at" json:"createdAt"`                                      // Creation timestamp
+	ExpiresAt         time.Time         `bson:"expires_at" json:"expiresAt"`                                      // Expiration timestamp
+	AdminExpiresAt    time.Time         `bson:"admin_expires_at" json:"adminExpiresAt"`                           // Expiration timestamp for admin access
+	UpdatedAt         time.Time         `bson:"updated_at" json:"updatedAt"`                                      // Last updated timestamp
+	SelfDestructAfter *Duration         `bson:"self_destruct_after,omitempty" json:"selfDestructAfter,omitempty"` // Optional lifetime counted from the first hit
+	FirstHitAt        *time.Time        `bson:"first_hit_at,omitempty" json:"firstHitAt,omitempty"`               // Time of the first successful redirect
+	SelfDestructsAt   *time.Time        `bson:"-" json:"selfDestructsAt,omitempty"`                               // When the self-destruct timer runs out, once started
+	Schedule          *Schedule         `bson:"schedule,omitempty" json:"schedule,omitempty"`                     // Optional recurring availability windows
+	FallbackTarget    *string           `bson:"fallback_target,omitempty" json:"fallbackTarget,omitempty"`        // Optional destination used while unavailable
+	FallbackOn        []Status          `bson:"fallback_on,omitempty" json:"fallbackOn,omitempty"`                // Unavailability reasons that use the fallback
+	FallbackHitCount  int               `bson:"fallback_hit_count" json:"fallbackHitCount"`                       // Number of fallback redirects so far
+	Variants          []Variant         `bson:"variants,omitempty" json:"variants,omitempty"`                     // Optional weighted targets with per-variant hit counts
+	StickyVariants    bool              `bson:"sticky_variants,omitempty" json:"stickyVariants,omitempty"`        // Whether visitors keep their first variant
+	Rules             []Rule            `bson:"rules,omitempty" json:"rules,omitempty"`                           // Ordered conditional targets tried before the default
+	AllowedCountries  []string          `bson:"allowed_countries,omitempty" json:"allowedCountries,omitempty"`    // Countries (ISO 3166-1 alpha-2) allowed to use the link
+	BlockedCountries  []string          `bson:"blocked_countries,omitempty" json:"blockedCountries,omitempty"`    // Countries refused access to the link
+	CountryTargets    map[string]string `bson:"country_targets,omitempty" json:"countryTargets,omitempty"`        // Per-country targets overriding the default
+	AllowedCIDRs      []string          `bson:"allowed_cidrs,omitempty" json:"allowedCidrs,omitempty"`            // Networks allowed to use the link
+	DeniedCIDRs       []string          `bson:"denied_cidrs,omitempty" json:"deniedCidrs,omitempty"`              // Networks refused access to the link
+	AllowedReferrers  []string          `bson:"allowed_referrers,omitempty" json:"allowedReferrers,omitempty"`    // Referrer domains the link may be followed from
+	NoReferrer        ReferrerPolicy    `bson:"no_referrer,omitempty" json:"noReferrer,omitempty"`                // Policy for requests without a referrer
+	Passthrough       *Passthrough      `bson:"passthrough,omitempty" json:"passthrough,omitempty"`               // Optional forwarding of the request path and query
+	Campaign          *Campaign         `bson:"campaign,omitempty" json:"campaign,omitempty"`                     // Optional UTM parameters added to the target
+	RedirectCode      int               `bson:"redirect_code,omitempty" json:"redirectCode,omitempty"`            // Optional redirect status code (default: server default)
+	Interstitial      *Interstitial     `bson:"interstitial,omitempty" json:"interstitial,omitempty"`             // Optional warning page shown before redirecting
+	Revision          int               `bson:"revision" json:"revision"`                                         // Number of patches applied so far
+	DisabledAt        *time.Time        `bson:"disabled_at,omitempty" json:"disabledAt,omitempty"`                // Set when an operator takes the link down
 }
 
 func (lnk *Link) ToPublic() *PublicLink {
 	return &PublicLink{
-		Slug:           lnk.Slug,
-		AdminToken:     lnk.AdminToken,
-		Target:         lnk.Target,
-		HitCount:       lnk.HitCount,
-		MaxHits:        lnk.MaxHits,
-		ValidFrom:      lnk.ValidFrom,
-		CreatedAt:      lnk.CreatedAt,
-		ExpiresAt:      lnk.ExpiresAt,
-		AdminExpiresAt: lnk.AdminExpiresAt,
-		UpdatedAt:      lnk.UpdatedAt,
+		Slug:              lnk.Slug,
+		AdminToken:        lnk.AdminToken,
+		Target:            lnk.Target,
+		HitCount:          lnk.HitCount,
+		MaxHits:           lnk.MaxHits,
+		ValidFrom:         lnk.ValidFrom,
+		CreatedAt:         lnk.CreatedAt,
+		ExpiresAt:         lnk.ExpiresAt,
+		AdminExpiresAt:    lnk.AdminExpiresAt,
+		UpdatedAt:         lnk.UpdatedAt,
+		SelfDestructAfter: lnk.SelfDestructAfter,
+		FirstHitAt:        lnk.FirstHitAt,
+		SelfDestructsAt:   lnk.SelfDestructsAt(),
+		Schedule:          lnk.Schedule,
+		FallbackTarget:    lnk.FallbackTarget,
+		FallbackOn:        lnk.FallbackOn,
+		FallbackHitCount:  lnk.FallbackHitCount,
+		Variants:          lnk.Variants,
+		StickyVariants:    lnk.StickyVariants,
+		Rules:             lnk.Rules,
+		AllowedCountries:  lnk.AllowedCountries,
+		BlockedCountries:  lnk.BlockedCountries,
+		CountryTargets:    lnk.CountryTargets,
+		AllowedCIDRs:      lnk.AllowedCIDRs,
+		DeniedCIDRs:       lnk.DeniedCIDRs,
+		AllowedReferrers:  lnk.AllowedReferrers,
+		NoReferrer:        lnk.NoReferrer,
+		Passthrough:       lnk.Passthrough,
+		Campaign:          lnk.Campaign,
+		RedirectCode:      lnk.RedirectCode,
+		Interstitial:      lnk.Interstitial,
+		Revision:          lnk.Revision,
+		DisabledAt:        lnk.DisabledAt,
 	}
 }
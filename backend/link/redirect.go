@@ -0,0 +1,26 @@
+package link
+
+import (
+	"net/http"
+)
+
+// IsRedirectCode reports whether code is a redirect status a link may use:
+// 302, 303, or 307. Permanent redirects (301, 308) are not allowed, since
+// every link expires and clients may cache them indefinitely.
+func IsRedirectCode(code int) bool {
+	switch code {
+	case http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect:
+		return true
+	default:
+		return false
+	}
+}
+
+// RedirectCodeOr returns the link's redirect status code, or def if the link
+// uses the server default.
+func (l *Link) RedirectCodeOr(def int) int {
+	if l.RedirectCode == 0 {
+		return def
+	}
+	return l.RedirectCode
+}
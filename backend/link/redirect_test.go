@@ -0,0 +1,55 @@
+package link
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRedirectCodeValidation(t *testing.T) {
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		code int
+		want error
+	}{
+		{0, nil},
+		{http.StatusFound, nil},
+		{http.StatusSeeOther, nil},
+		{http.StatusTemporaryRedirect, nil},
+		{http.StatusMovedPermanently, ErrInvalidRedirectCode},
+		{http.StatusPermanentRedirect, ErrInvalidRedirectCode},
+		{http.StatusOK, ErrInvalidRedirectCode},
+		{http.StatusNotModified, ErrInvalidRedirectCode},
+		{http.StatusUseProxy, ErrInvalidRedirectCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.code), func(t *testing.T) {
+			lnk := &Link{
+				Target:         "https://example.com",
+				CreatedAt:      now,
+				ExpiresAt:      now.Add(time.Hour),
+				AdminExpiresAt: now.Add(2 * time.Hour),
+				RedirectCode:   tt.code,
+			}
+			if _, err := Validate(lnk, now); !errors.Is(err, tt.want) {
+				t.Errorf("Validate: err = %v, want %v", err, tt.want)
+			}
+
+			original := &Link{
+				Target:         "https://example.com",
+				CreatedAt:      now,
+				ExpiresAt:      now.Add(time.Hour),
+				AdminExpiresAt: now.Add(2 * time.Hour),
+			}
+			patch := NewPatchLink(now)
+			patch.RedirectCode.Value = &tt.code
+			if _, err := ValidatePatch(original, patch, now); !errors.Is(err, tt.want) {
+				t.Errorf("ValidatePatch: err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package link
+
+import (
+	"slices"
+)
+
+// maxReferrers is the maximum number of allowed referrer domains on a link.
+const maxReferrers = 50
+
+// ReferrerPolicy decides whether requests without a Referer header may use a
+// link that restricts referrers.
+type ReferrerPolicy string
+
+// Policies for requests without a Referer header.
+const (
+	ReferrerAllow ReferrerPolicy = "allow"
+	ReferrerDeny  ReferrerPolicy = "deny"
+)
+
+// AllowsReferrer reports whether a request referred from host ("" if it has no
+// Referer) may use the link. Requests without a referrer follow the link's
+// NoReferrer policy, or defaultPolicy if the link has none.
+func (l *Link) AllowsReferrer(host string, defaultPolicy ReferrerPolicy) bool {
+	if len(l.AllowedReferrers) == 0 {
+		return true
+	}
+	if host == "" {
+		policy := l.NoReferrer
+		if policy == "" {
+			policy = defaultPolicy
+		}
+		return policy != ReferrerDeny
+	}
+	return slices.ContainsFunc(l.AllowedReferrers, func(domain string) bool {
+		return MatchesDomain(host, domain)
+	})
+}
@@ -2,8 +2,14 @@ package link
 
 import (
 	"context"
+	"errors"
+	"time"
 )
 
+// ErrConflict is returned when a link was modified between reading it and
+// applying a patch.
+var ErrConflict = errors.New("link was modified concurrently")
+
 // Repository defines persistence operations for Link objects.
 type Repository interface {
 	// Create inserts a new link into the repository.
@@ -15,12 +21,86 @@ type Repository interface {
 	// IncBySlug increments the hit count for the given slug.
 	IncBySlug(ctx context.Context, slug string) error
 
+	// IncVariantBySlug increments the hit count for the given slug and for the
+	// named variant. The link's hit is counted even if it no longer has the
+	// variant.
+	IncVariantBySlug(ctx context.Context, slug, variant string) error
+
+	// IncFallbackBySlug increments the fallback hit count for the given slug.
+	IncFallbackBySlug(ctx context.Context, slug string) error
+
 	// GetByToken retrieves a link by its admin token.
 	GetByToken(ctx context.Context, token string) (*Link, error)
 
 	// DeleteByToken removes a link by its admin token.
 	DeleteByToken(ctx context.Context, token string) error
 
-	// PatchByToken updates a link by its admin token.
+	// PatchByToken updates a link by its admin token and records the replaced
+	// state as a revision. Returns ErrConflict if the link changed since the
+	// patch was validated.
 	PatchByToken(ctx context.Context, token string, patch *ValidatedPatch) error
+
+	// ListRevisionsByToken returns the recorded revisions of a link, newest first.
+	ListRevisionsByToken(ctx context.Context, token string) ([]Revision, error)
+
+	// GetRevisionByToken retrieves a single revision of a link by its number.
+	// Returns a nil Revision if one is not found.
+	GetRevisionByToken(ctx context.Context, token string, number int) (*Revision, error)
+}
+
+// Pinger is optionally implemented by stores backing a Repository to report
+// whether they are reachable and ready to serve requests.
+type Pinger interface {
+	// Ping returns an error if the store cannot currently serve requests.
+	Ping(ctx context.Context) error
+}
+
+// Maintainer is optionally implemented by stores backing a Repository to let
+// operators act on stored links outside of the public API.
+type Maintainer interface {
+	// FindByTargetDomain returns the links that may redirect to domain or one
+	// of its subdomains.
+	FindByTargetDomain(ctx context.Context, domain string) ([]Link, error)
+
+	// DisableBySlug takes down the link with the given slug as of at. Returns
+	// false if no link has the slug.
+	DisableBySlug(ctx context.Context, slug string, at time.Time) (bool, error)
+
+	// EnableBySlug restores a link taken down with DisableBySlug. Returns
+	// false if no link has the slug.
+	EnableBySlug(ctx context.Context, slug string) (bool, error)
+
+	// CountExpired returns the number of links whose admin access expired
+	// before the given time.
+	CountExpired(ctx context.Context, before time.Time) (int64, error)
+
+	// PurgeExpired deletes the links whose admin access expired before the
+	// given time and returns how many were deleted.
+	PurgeExpired(ctx context.Context, before time.Time) (int64, error)
+
+	// MissingIndexes returns the names of required indexes that don't exist.
+	MissingIndexes(ctx context.Context) ([]string, error)
+
+	// EnsureIndexes creates any missing required indexes.
+	EnsureIndexes(ctx context.Context) error
+
+	// CheckIntegrity scans every stored link and reports the ones that break
+	// an invariant the application relies on.
+	CheckIntegrity(ctx context.Context) ([]IntegrityIssue, error)
+}
+
+// Kinds of integrity issues.
+const (
+	IssueDuplicateSlug       = "duplicate_slug"
+	IssueDuplicateAdminToken = "duplicate_admin_token"
+	IssueMissingField        = "missing_field"
+	IssueSchemaVersion       = "schema_version"
+)
+
+// IntegrityIssue describes a stored link that breaks an invariant.
+type IntegrityIssue struct {
+	Kind   string `json:"kind"`           // One of the Issue* kinds
+	ID     string `json:"id"`             // Database identifier of the link
+	Slug   string `json:"slug,omitempty"` // Slug of the link, if it has one
+	Detail string `json:"detail"`         // Human-readable description
 }
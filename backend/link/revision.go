@@ -0,0 +1,237 @@
+package link
+
+import (
+	"maps"
+	"slices"
+	"time"
+)
+
+// Revision is a snapshot of a link's editable fields as they were before a
+// patch replaced them.
+type Revision struct {
+	Number            int               `bson:"number" json:"number"`                                             // Revision number of the snapshot
+	Target            string            `bson:"target" json:"target"`                                             // Destination URL
+	MaxHits           *int              `bson:"max_hits,omitempty" json:"maxHits,omitempty"`                      // Optional max allowed hits
+	PasswordHash      *string           `bson:"password_hash,omitempty" json:"-"`                                 // Optional password hash (not exposed in JSON)
+	HasPassword       bool              `bson:"-" json:"hasPassword"`                                             // Whether the revision was password protected
+	ValidFrom         *time.Time        `bson:"valid_from,omitempty" json:"validFrom,omitempty"`                  // Optional start validity timestamp
+	SelfDestructAfter *Duration         `bson:"self_destruct_after,omitempty" json:"selfDestructAfter,omitempty"` // Optional lifetime counted from the first hit
+	Schedule          *Schedule         `bson:"schedule,omitempty" json:"schedule,omitempty"`                     // Optional recurring availability windows
+	FallbackTarget    *string           `bson:"fallback_target,omitempty" json:"fallbackTarget,omitempty"`        // Optional destination used while unavailable
+	FallbackOn        []Status          `bson:"fallback_on,omitempty" json:"fallbackOn,omitempty"`                // Unavailability reasons that use the fallback
+	Variants          []Variant         `bson:"variants,omitempty" json:"variants,omitempty"`                     // Optional weighted targets used instead of Target
+	StickyVariants    bool              `bson:"sticky_variants,omitempty" json:"stickyVariants,omitempty"`        // Whether visitors keep their first variant
+	Rules             []Rule            `bson:"rules,omitempty" json:"rules,omitempty"`                           // Ordered conditional targets tried before the default
+	AllowedCountries  []string          `bson:"allowed_countries,omitempty" json:"allowedCountries,omitempty"`    // Countries (ISO 3166-1 alpha-2) allowed to use the link
+	BlockedCountries  []string          `bson:"blocked_countries,omitempty" json:"blockedCountries,omitempty"`    // Countries refused access to the link
+	CountryTargets    map[string]string `bson:"country_targets,omitempty" json:"countryTargets,omitempty"`        // Per-country targets overriding the default
+	AllowedCIDRs      []string          `bson:"allowed_cidrs,omitempty" json:"allowedCidrs,omitempty"`            // Networks allowed to use the link
+	DeniedCIDRs       []string          `bson:"denied_cidrs,omitempty" json:"deniedCidrs,omitempty"`              // Networks refused access to the link
+	AllowedReferrers  []string          `bson:"allowed_referrers,omitempty" json:"allowedReferrers,omitempty"`    // Referrer domains the link may be followed from
+	NoReferrer        ReferrerPolicy    `bson:"no_referrer,omitempty" json:"noReferrer,omitempty"`                // Policy for requests without a referrer
+	Passthrough       *Passthrough      `bson:"passthrough,omitempty" json:"passthrough,omitempty"`               // Optional forwarding of the request path and query
+	Campaign          *Campaign         `bson:"campaign,omitempty" json:"campaign,omitempty"`                     // Optional UTM parameters added to the target
+	RedirectCode      int               `bson:"redirect_code,omitempty" json:"redirectCode,omitempty"`            // Optional redirect status code (default: server default)
+	Interstitial      *Interstitial     `bson:"interstitial,omitempty" json:"interstitial,omitempty"`             // Optional warning page shown before redirecting
+	ExpiresAt         time.Time         `bson:"expires_at" json:"expiresAt"`                                      // Expiration timestamp
+	AdminExpiresAt    time.Time         `bson:"admin_expires_at" json:"adminExpiresAt"`                           // Expiration timestamp for admin access
+	ReplacedAt        time.Time         `bson:"replaced_at" json:"replacedAt"`                                    // When a patch replaced this revision
+}
+
+// snapshot captures the link's editable fields as a Revision replaced at now.
+func (l *Link) snapshot(now time.Time) *Revision {
+	return &Revision{
+		Number:            l.Revision,
+		Target:            l.Target,
+		MaxHits:           l.MaxHits,
+		PasswordHash:      l.PasswordHash,
+		ValidFrom:         l.ValidFrom,
+		SelfDestructAfter: l.SelfDestructAfter,
+		Schedule:          l.Schedule,
+		FallbackTarget:    l.FallbackTarget,
+		FallbackOn:        l.FallbackOn,
+		Variants:          l.Variants,
+		StickyVariants:    l.StickyVariants,
+		Rules:             l.Rules,
+		AllowedCountries:  l.AllowedCountries,
+		BlockedCountries:  l.BlockedCountries,
+		CountryTargets:    l.CountryTargets,
+		AllowedCIDRs:      l.AllowedCIDRs,
+		DeniedCIDRs:       l.DeniedCIDRs,
+		AllowedReferrers:  l.AllowedReferrers,
+		NoReferrer:        l.NoReferrer,
+		Passthrough:       l.Passthrough,
+		Campaign:          l.Campaign,
+		RedirectCode:      l.RedirectCode,
+		Interstitial:      l.Interstitial,
+		ExpiresAt:         l.ExpiresAt,
+		AdminExpiresAt:    l.AdminExpiresAt,
+		ReplacedAt:        now,
+	}
+}
+
+// RollbackPatch builds a PatchLink restoring the editable fields of original
+// to the state recorded in rev. Only fields that differ are included.
+// The result must still be validated with ValidatePatch.
+func RollbackPatch(original *Link, rev *Revision, now time.Time) *PatchLink {
+	patch := NewPatchLink(now)
+
+	if rev.Target != original.Target {
+		target := rev.Target
+		patch.Target = &target
+	}
+
+	if !rev.ExpiresAt.Equal(original.ExpiresAt) {
+		expiresAt := rev.ExpiresAt
+		patch.ExpiresAt = &expiresAt
+		adminExpiresAt := rev.AdminExpiresAt
+		patch.AdminExpiresAt = &adminExpiresAt
+	}
+
+	if rev.MaxHits == nil {
+		patch.MaxHits.Remove = original.MaxHits != nil
+	} else if original.MaxHits == nil || *rev.MaxHits != *original.MaxHits {
+		maxHits := *rev.MaxHits
+		patch.MaxHits.Value = &maxHits
+	}
+
+	if rev.ValidFrom == nil {
+		patch.ValidFrom.Remove = original.ValidFrom != nil
+	} else if original.ValidFrom == nil || !rev.ValidFrom.Equal(*original.ValidFrom) {
+		validFrom := *rev.ValidFrom
+		patch.ValidFrom.Value = &validFrom
+	}
+
+	if rev.SelfDestructAfter == nil {
+		patch.SelfDestructAfter.Remove = original.SelfDestructAfter != nil
+	} else if original.SelfDestructAfter == nil || *rev.SelfDestructAfter != *original.SelfDestructAfter {
+		selfDestructAfter := *rev.SelfDestructAfter
+		patch.SelfDestructAfter.Value = &selfDestructAfter
+	}
+
+	if rev.Schedule == nil {
+		patch.Schedule.Remove = original.Schedule != nil
+	} else {
+		schedule := *rev.Schedule
+		patch.Schedule.Value = &schedule
+	}
+
+	if rev.FallbackTarget == nil {
+		patch.FallbackTarget.Remove = original.FallbackTarget != nil
+	} else if original.FallbackTarget == nil || *rev.FallbackTarget != *original.FallbackTarget {
+		fallbackTarget := *rev.FallbackTarget
+		patch.FallbackTarget.Value = &fallbackTarget
+	}
+
+	if len(rev.FallbackOn) == 0 {
+		patch.FallbackOn.Remove = len(original.FallbackOn) != 0
+	} else if !slices.Equal(rev.FallbackOn, original.FallbackOn) {
+		fallbackOn := slices.Clone(rev.FallbackOn)
+		patch.FallbackOn.Value = &fallbackOn
+	}
+
+	if len(rev.Variants) == 0 {
+		patch.Variants.Remove = len(original.Variants) != 0
+	} else {
+		variants := slices.Clone(rev.Variants)
+		patch.Variants.Value = &variants
+	}
+
+	if rev.StickyVariants != original.StickyVariants {
+		sticky := rev.StickyVariants
+		patch.StickyVariants = &sticky
+	}
+
+	if len(rev.Rules) == 0 {
+		patch.Rules.Remove = len(original.Rules) != 0
+	} else {
+		rules := slices.Clone(rev.Rules)
+		patch.Rules.Value = &rules
+	}
+
+	if len(rev.AllowedCountries) == 0 {
+		patch.AllowedCountries.Remove = len(original.AllowedCountries) != 0
+	} else if !slices.Equal(rev.AllowedCountries, original.AllowedCountries) {
+		countries := slices.Clone(rev.AllowedCountries)
+		patch.AllowedCountries.Value = &countries
+	}
+
+	if len(rev.BlockedCountries) == 0 {
+		patch.BlockedCountries.Remove = len(original.BlockedCountries) != 0
+	} else if !slices.Equal(rev.BlockedCountries, original.BlockedCountries) {
+		countries := slices.Clone(rev.BlockedCountries)
+		patch.BlockedCountries.Value = &countries
+	}
+
+	if len(rev.CountryTargets) == 0 {
+		patch.CountryTargets.Remove = len(original.CountryTargets) != 0
+	} else if !maps.Equal(rev.CountryTargets, original.CountryTargets) {
+		targets := maps.Clone(rev.CountryTargets)
+		patch.CountryTargets.Value = &targets
+	}
+
+	if len(rev.AllowedCIDRs) == 0 {
+		patch.AllowedCIDRs.Remove = len(original.AllowedCIDRs) != 0
+	} else if !slices.Equal(rev.AllowedCIDRs, original.AllowedCIDRs) {
+		cidrs := slices.Clone(rev.AllowedCIDRs)
+		patch.AllowedCIDRs.Value = &cidrs
+	}
+
+	if len(rev.DeniedCIDRs) == 0 {
+		patch.DeniedCIDRs.Remove = len(original.DeniedCIDRs) != 0
+	} else if !slices.Equal(rev.DeniedCIDRs, original.DeniedCIDRs) {
+		cidrs := slices.Clone(rev.DeniedCIDRs)
+		patch.DeniedCIDRs.Value = &cidrs
+	}
+
+	if len(rev.AllowedReferrers) == 0 {
+		patch.AllowedReferrers.Remove = len(original.AllowedReferrers) != 0
+	} else if !slices.Equal(rev.AllowedReferrers, original.AllowedReferrers) {
+		referrers := slices.Clone(rev.AllowedReferrers)
+		patch.AllowedReferrers.Value = &referrers
+	}
+
+	if rev.NoReferrer == "" {
+		patch.NoReferrer.Remove = original.NoReferrer != ""
+	} else if rev.NoReferrer != original.NoReferrer {
+		policy := rev.NoReferrer
+		patch.NoReferrer.Value = &policy
+	}
+
+	if rev.Passthrough == nil {
+		patch.Passthrough.Remove = original.Passthrough != nil
+	} else {
+		passthrough := *rev.Passthrough
+		patch.Passthrough.Value = &passthrough
+	}
+
+	if rev.Campaign == nil {
+		patch.Campaign.Remove = original.Campaign != nil
+	} else if original.Campaign == nil || *rev.Campaign != *original.Campaign {
+		campaign := *rev.Campaign
+		patch.Campaign.Value = &campaign
+	}
+
+	if rev.RedirectCode == 0 {
+		patch.RedirectCode.Remove = original.RedirectCode != 0
+	} else if rev.RedirectCode != original.RedirectCode {
+		code := rev.RedirectCode
+		patch.RedirectCode.Value = &code
+	}
+
+	if rev.Interstitial == nil {
+		patch.Interstitial.Remove = original.Interstitial != nil
+	} else if original.Interstitial == nil || *rev.Interstitial != *original.Interstitial {
+		interstitial := *rev.Interstitial
+		patch.Interstitial.Value = &interstitial
+	}
+
+	if rev.PasswordHash == nil {
+		patch.PasswordHash.Remove = original.PasswordHash != nil
+	} else if original.PasswordHash == nil || *rev.PasswordHash != *original.PasswordHash {
+		passwordHash := *rev.PasswordHash
+		patch.PasswordHash.Value = &passwordHash
+	}
+
+	return patch
+}
@@ -0,0 +1,131 @@
+package link
+
+import (
+	"net/netip"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	// maxRules is the maximum number of routing rules on a link.
+	maxRules = 20
+
+	// maxRuleValues is the maximum number of values in each rule condition.
+	maxRuleValues = 20
+
+	// AnyValue matches any value of a query parameter that is present.
+	AnyValue = "*"
+)
+
+// Device classes a rule can match.
+const (
+	DeviceIOS     = "ios"
+	DeviceAndroid = "android"
+	DeviceDesktop = "desktop"
+)
+
+var (
+	// languagePattern matches language tags such as "de" or "pt-BR".
+	languagePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+	// domainPattern matches bare domain names such as "news.example.com".
+	domainPattern = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?\.)+[A-Za-z]{2,63}$`)
+)
+
+// Rule routes requests matching every one of its conditions to Target.
+// Empty conditions match any request, but each rule must have at least one.
+type Rule struct {
+	Devices   []string          `bson:"devices,omitempty" json:"devices,omitempty"`     // Device classes: ios, android, desktop
+	Languages []string          `bson:"languages,omitempty" json:"languages,omitempty"` // Preferred languages, e.g. "de" (any region) or "pt-BR"
+	Referrers []string          `bson:"referrers,omitempty" json:"referrers,omitempty"` // Referrer domains, including their subdomains
+	Query     map[string]string `bson:"query,omitempty" json:"query,omitempty"`         // Required query parameter values ("*" for any)
+	Target    string            `bson:"target" json:"target"`                           // Destination URL
+}
+
+// RequestInfo holds the properties of an incoming request that rules can
+// match on.
+type RequestInfo struct {
+	Device       string     // Device class: ios, android, or desktop
+	Language     string     // Most preferred language tag, or ""
+	ReferrerHost string     // Host of the referrer, or ""
+	Country      string     // ISO 3166-1 alpha-2 country code, or ""
+	IP           netip.Addr // Client IP address, or the zero Addr
+	Path         []string   // Path segments following the slug
+	Query        url.Values // Query parameters
+}
+
+// Allows reports whether the request may use the link: its network, country
+// and referrer must all be admitted.
+func (l *Link) Allows(info RequestInfo, noReferrer ReferrerPolicy) bool {
+	return l.AllowsIP(info.IP) && l.AllowsCountry(info.Country) && l.AllowsReferrer(info.ReferrerHost, noReferrer)
+}
+
+// MatchRule returns the index and the first rule matching the request, or -1
+// and nil if none does.
+func (l *Link) MatchRule(info RequestInfo) (int, *Rule) {
+	for i := range l.Rules {
+		if l.Rules[i].matches(info) {
+			return i, &l.Rules[i]
+		}
+	}
+	return -1, nil
+}
+
+// matches reports whether every condition of the rule matches the request.
+func (rule *Rule) matches(info RequestInfo) bool {
+	if len(rule.Devices) != 0 && !slices.Contains(rule.Devices, info.Device) {
+		return false
+	}
+
+	if len(rule.Languages) != 0 && !slices.ContainsFunc(rule.Languages, func(lang string) bool {
+		return matchesLanguage(lang, info.Language)
+	}) {
+		return false
+	}
+
+	if len(rule.Referrers) != 0 && !slices.ContainsFunc(rule.Referrers, func(domain string) bool {
+		return MatchesDomain(info.ReferrerHost, domain)
+	}) {
+		return false
+	}
+
+	for key, want := range rule.Query {
+		if !info.Query.Has(key) {
+			return false
+		}
+		if want != AnyValue && info.Query.Get(key) != want {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hasConditions reports whether the rule has at least one condition.
+func (rule *Rule) hasConditions() bool {
+	return len(rule.Devices) != 0 || len(rule.Languages) != 0 || len(rule.Referrers) != 0 || len(rule.Query) != 0
+}
+
+// matchesLanguage reports whether the visitor's language tag matches the
+// rule's tag. A tag without a region matches every region.
+func matchesLanguage(rule, visitor string) bool {
+	if visitor == "" {
+		return false
+	}
+	if strings.EqualFold(rule, visitor) {
+		return true
+	}
+	return !strings.Contains(rule, "-") && strings.HasPrefix(strings.ToLower(visitor), strings.ToLower(rule)+"-")
+}
+
+// MatchesDomain reports whether host is domain or one of its subdomains.
+func MatchesDomain(host, domain string) bool {
+	if host == "" {
+		return false
+	}
+	host = strings.ToLower(host)
+	domain = strings.ToLower(domain)
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
@@ -0,0 +1,152 @@
+package link
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxScheduleWindows is the maximum number of weekly windows in a schedule.
+	maxScheduleWindows = 28
+
+	// maxScheduleExceptions is the maximum number of exception dates in a schedule.
+	maxScheduleExceptions = 100
+
+	// dateLayout is the layout of schedule exception dates.
+	dateLayout = time.DateOnly
+
+	// clockLayout is the layout of window start and end times.
+	clockLayout = "15:04"
+)
+
+// weekdays maps the accepted day names to time.Weekday values.
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// locations caches loaded time zones by name.
+var locations sync.Map
+
+// loadLocation returns the named time zone, loading it at most once.
+func loadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// Schedule restricts a link to recurring weekly time windows in a time zone.
+// The link is closed outside of every window and on every exception date.
+type Schedule struct {
+	TimeZone   string   `bson:"time_zone" json:"timeZone"`                        // IANA time zone, e.g. "Europe/Berlin"
+	Windows    []Window `bson:"windows" json:"windows"`                           // Weekly windows during which the link is open
+	Exceptions []string `bson:"exceptions,omitempty" json:"exceptions,omitempty"` // Dates (YYYY-MM-DD) on which the link is closed
+}
+
+// Window is a daily time range repeated on the given days of the week.
+type Window struct {
+	Days  []string `bson:"days" json:"days"`   // Days of the week: "mon" through "sun"
+	Start string   `bson:"start" json:"start"` // Opening time (HH:MM, inclusive)
+	End   string   `bson:"end" json:"end"`     // Closing time (HH:MM, exclusive; "24:00" for midnight)
+}
+
+// IsOpen reports whether the schedule is open at t.
+func (s *Schedule) IsOpen(t time.Time) bool {
+	next := s.NextOpening(t, t.Add(time.Nanosecond))
+	return next != nil && next.Equal(t)
+}
+
+// NextOpening returns the earliest time at or after from when the schedule is
+// open, or nil if it does not open before until.
+// The schedule must have been validated.
+func (s *Schedule) NextOpening(from, until time.Time) *time.Time {
+	loc, err := loadLocation(s.TimeZone)
+	if err != nil {
+		return nil
+	}
+
+	exceptions := make(map[string]bool, len(s.Exceptions))
+	for _, date := range s.Exceptions {
+		exceptions[date] = true
+	}
+
+	local := from.In(loc)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	for ; day.Before(until); day = day.AddDate(0, 0, 1) {
+		if exceptions[day.Format(dateLayout)] {
+			continue
+		}
+
+		var earliest *time.Time
+		for _, w := range s.Windows {
+			if !w.includes(day.Weekday()) {
+				continue
+			}
+			start, end := w.bounds(day)
+			if !end.After(from) {
+				continue
+			}
+			if start.Before(from) {
+				start = from
+			}
+			if earliest == nil || start.Before(*earliest) {
+				earliest = &start
+			}
+		}
+
+		if earliest != nil {
+			if !earliest.Before(until) {
+				return nil
+			}
+			return earliest
+		}
+	}
+
+	return nil
+}
+
+// includes reports whether the window applies on the given weekday.
+func (w *Window) includes(weekday time.Weekday) bool {
+	for _, d := range w.Days {
+		if weekdays[strings.ToLower(d)] == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// bounds returns the start and end of the window on the given day, which must
+// be midnight in the schedule's location.
+func (w *Window) bounds(day time.Time) (start, end time.Time) {
+	startMin, _ := parseClock(w.Start)
+	endMin, _ := parseClock(w.End)
+	start = time.Date(day.Year(), day.Month(), day.Day(), startMin/60, startMin%60, 0, 0, day.Location())
+	end = time.Date(day.Year(), day.Month(), day.Day(), endMin/60, endMin%60, 0, 0, day.Location())
+	return start, end
+}
+
+// parseClock parses an HH:MM time of day into minutes after midnight.
+// "24:00" is accepted as the end of the day.
+func parseClock(s string) (int, error) {
+	if s == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse(clockLayout, s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q: must be HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,40 @@
+package link
+
+import (
+	"time"
+)
+
+// Stats summarizes the usage of a link.
+type Stats struct {
+	Status           Status         `json:"status"`                  // Availability at the time of the request
+	HitCount         int            `json:"hitCount"`                // Number of redirects so far
+	MaxHits          *int           `json:"maxHits,omitempty"`       // Optional max allowed hits
+	RemainingHits    *int           `json:"remainingHits,omitempty"` // Hits left before the link is exhausted
+	FallbackHitCount int            `json:"fallbackHitCount"`        // Number of fallback redirects so far
+	FirstHitAt       *time.Time     `json:"firstHitAt,omitempty"`    // Time of the first successful redirect
+	ExpiresAt        time.Time      `json:"expiresAt"`               // When the link expires, including any self-destruct timer
+	Variants         []VariantStats `json:"variants,omitempty"`      // Hits per variant of a split link
+}
+
+// VariantStats holds the number of redirects to one variant.
+type VariantStats struct {
+	Name     string `json:"name"`
+	HitCount int    `json:"hitCount"`
+}
+
+// Stats returns the usage statistics of the link at the given time.
+func (l *Link) Stats(now time.Time) Stats {
+	stats := Stats{
+		Status:           l.Status(now),
+		HitCount:         l.HitCount,
+		MaxHits:          l.MaxHits,
+		RemainingHits:    l.RemainingHits(),
+		FallbackHitCount: l.FallbackHitCount,
+		FirstHitAt:       l.FirstHitAt,
+		ExpiresAt:        l.EffectiveExpiresAt(),
+	}
+	for _, v := range l.Variants {
+		stats.Variants = append(stats.Variants, VariantStats{Name: v.Name, HitCount: v.HitCount})
+	}
+	return stats
+}
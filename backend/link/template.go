@@ -0,0 +1,92 @@
+package link
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPathVar is the highest path segment a template may refer to.
+const maxPathVar = 20
+
+// placeholderPattern matches template variables such as {lang}, {query.code}
+// or {path.1}.
+var placeholderPattern = regexp.MustCompile(`\{([a-z]+)(?:\.([A-Za-z0-9_-]{1,64}))?\}`)
+
+// Template variables usable in targets.
+//   - {query.NAME}: value of the incoming query parameter NAME
+//   - {path.N}: N-th path segment after the slug, starting at 1
+//   - {lang}: visitor's preferred language
+//   - {country}: visitor's country code
+//   - {timestamp}: Unix time of the redirect in seconds
+const (
+	varQuery     = "query"
+	varPath      = "path"
+	varLang      = "lang"
+	varCountry   = "country"
+	varTimestamp = "timestamp"
+)
+
+// IsTemplate reports whether target contains template variables.
+func IsTemplate(target string) bool {
+	return strings.ContainsAny(target, "{}")
+}
+
+// RenderTarget fills in the template variables of target from the request.
+// Values are escaped for the part of the URL they appear in, and missing
+// values are left empty. The target must have been validated, so variables
+// can only appear after the host.
+func RenderTarget(target string, info RequestInfo, now time.Time) string {
+	if !IsTemplate(target) {
+		return target
+	}
+
+	queryStart := strings.IndexAny(target, "?#")
+	if queryStart < 0 {
+		queryStart = len(target)
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range placeholderPattern.FindAllStringSubmatchIndex(target, -1) {
+		b.WriteString(target[last:m[0]])
+		last = m[1]
+
+		var key string
+		if m[4] >= 0 {
+			key = target[m[4]:m[5]]
+		}
+		value := templateValue(target[m[2]:m[3]], key, info, now)
+		if m[0] < queryStart {
+			b.WriteString(url.PathEscape(value))
+		} else {
+			b.WriteString(url.QueryEscape(value))
+		}
+	}
+	b.WriteString(target[last:])
+	return b.String()
+}
+
+// templateValue returns the unescaped value of a template variable.
+func templateValue(name, key string, info RequestInfo, now time.Time) string {
+	switch name {
+	case varQuery:
+		return info.Query.Get(key)
+	case varPath:
+		n, _ := strconv.Atoi(key)
+		if n < 1 || n > len(info.Path) {
+			return ""
+		}
+		return info.Path[n-1]
+	case varLang:
+		return info.Language
+	case varCountry:
+		return info.Country
+	case varTimestamp:
+		return strconv.FormatInt(now.Unix(), 10)
+	default:
+		return ""
+	}
+}
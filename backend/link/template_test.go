@@ -0,0 +1,63 @@
+package link
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func FuzzRenderTarget(f *testing.F) {
+	seeds := []string{
+		"https://example.com/{path.1}",
+		"https://example.com/docs/{lang}/{path.2}?q={query.q}",
+		"http://example.com:8080/{country}?t={timestamp}#{query.section}",
+		"https://user@example.com/a/{path.1}/b?x=1&y={query.y}",
+		"https://[2001:db8::1]/{path.1}{path.2}",
+	}
+	for _, target := range seeds {
+		f.Add(target, "q=@evil.com&y=//evil.com", "..%2F/evil.com/@x", "en-US", "GB")
+		f.Add(target, "q=%0d%0aLocation:%20https://evil.com", "a/b?c#d", "../../", "//")
+	}
+
+	now := time.Unix(1_700_000_000, 0)
+	f.Fuzz(func(t *testing.T, target, rawQuery, path, lang, country string) {
+		if validateTarget(target) != nil {
+			t.Skip()
+		}
+		query, _ := url.ParseQuery(rawQuery)
+		info := RequestInfo{
+			Language: lang,
+			Country:  country,
+			Path:     strings.Split(path, "/"),
+			Query:    query,
+		}
+
+		rendered := RenderTarget(target, info, now)
+
+		schemeEnd := strings.Index(target, "://") + 3
+		authorityEnd := schemeEnd + strings.IndexAny(target[schemeEnd:], "/?#")
+		if authorityEnd < schemeEnd {
+			authorityEnd = len(target)
+		}
+		authority := target[:authorityEnd]
+		if !strings.HasPrefix(rendered, authority) {
+			t.Fatalf("RenderTarget(%q) = %q, lost scheme and authority %q", target, rendered, authority)
+		}
+		if rest := rendered[len(authority):]; rest != "" && !strings.ContainsRune("/?#", rune(rest[0])) {
+			t.Fatalf("RenderTarget(%q) = %q, extended the authority %q", target, rendered, authority)
+		}
+
+		want, err := url.Parse(placeholderPattern.ReplaceAllString(target, ""))
+		if err != nil {
+			return
+		}
+		got, err := url.Parse(rendered)
+		if err != nil {
+			return
+		}
+		if got.Scheme != want.Scheme || got.Host != want.Host || got.User.String() != want.User.String() {
+			t.Fatalf("RenderTarget(%q) = %q, changed scheme or host from %s://%s to %s://%s", target, rendered, want.Scheme, want.Host, got.Scheme, got.Host)
+		}
+	})
+}
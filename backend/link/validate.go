@@ -1,11 +1,15 @@
 package link
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 )
 
 var (
@@ -13,6 +17,9 @@ var (
 	ErrURLSchemeNotHTTPorHTTPS = errors.New("URL must start with http or https")
 	ErrURLMissingHost          = errors.New("URL must include a valid host")
 
+	ErrInvalidTemplate     = errors.New("target template variables must be one of {query.NAME}, {path.N}, {lang}, {country}, {timestamp}")
+	ErrTemplateInAuthority = errors.New("target template variables may only appear after the host")
+
 	ErrExpiresAtTooSoon = fmt.Errorf("expiration time must be at least %d minute from now", minTime)
 	ErrExpiresAtTooFar  = fmt.Errorf("expiration time must be within the next %d days", maxTime)
 
@@ -24,12 +31,73 @@ var (
 	ErrValidFromTooSoon = fmt.Errorf("start time must be at least %d minute from now", minTime)
 	ErrValidFromTooFar  = fmt.Errorf("start time must be within the next %d days", maxTime)
 
-	ErrValidFromAfterExpiresAt   = errors.New("start time must be before expiration time")
+	ErrValidFromAfterExpiresAt = errors.New("start time must be before expiration time")
+
+	ErrScheduleMissingTimeZone = errors.New("schedule time zone is required")
+	ErrScheduleInvalidTimeZone = errors.New("schedule time zone must be a valid IANA time zone")
+	ErrScheduleNoWindows       = fmt.Errorf("schedule must have between 1 and %d windows", maxScheduleWindows)
+	ErrScheduleWindowNoDays    = errors.New("schedule window must include at least one day")
+	ErrScheduleInvalidDay      = errors.New("schedule days must be one of mon, tue, wed, thu, fri, sat, sun")
+	ErrScheduleInvalidWindow   = errors.New("schedule window start must be before its end")
+	ErrScheduleTooManyExcepts  = fmt.Errorf("schedule must have at most %d exception dates", maxScheduleExceptions)
+	ErrScheduleInvalidExcept   = errors.New("schedule exception dates must be YYYY-MM-DD")
+
+	ErrTargetAndVariants    = errors.New("only one of target or variants may be set")
+	ErrVariantCount         = fmt.Errorf("variants must contain between %d and %d entries", minVariants, maxVariants)
+	ErrInvalidVariantName   = errors.New("variant names must be 1-32 letters, digits, '-' or '_'")
+	ErrDuplicateVariantName = errors.New("variant names must be unique")
+	ErrInvalidVariantWeight = fmt.Errorf("variant weights must be between 1 and %d", maxVariantWeight)
+
+	ErrTooManyRules         = fmt.Errorf("rules must contain at most %d entries", maxRules)
+	ErrRuleWithoutCondition = errors.New("each rule must have at least one condition")
+	ErrTooManyRuleValues    = fmt.Errorf("each rule condition must have at most %d values", maxRuleValues)
+	ErrInvalidRuleDevice    = errors.New("rule devices must be one of ios, android, desktop")
+	ErrInvalidRuleLanguage  = errors.New("rule languages must be language tags such as de or pt-BR")
+	ErrInvalidRuleReferrer  = errors.New("rule referrers must be domain names such as example.com")
+	ErrInvalidRuleQuery     = errors.New("rule query parameter names must not be empty")
+
+	ErrAllowedAndBlockedCountries = errors.New("only one of allowedCountries or blockedCountries may be set")
+	ErrTooManyCountries           = fmt.Errorf("country lists must contain at most %d entries", maxCountries)
+	ErrInvalidCountry             = errors.New("countries must be ISO 3166-1 alpha-2 codes such as DE")
+
+	ErrTooManyCIDRs = fmt.Errorf("network lists must contain at most %d entries", maxCIDRs)
+	ErrInvalidCIDR  = errors.New("networks must be in CIDR notation (e.g. 10.0.0.0/8) or single IP addresses")
+
+	ErrTooManyReferrers         = fmt.Errorf("allowed referrers must contain at most %d entries", maxReferrers)
+	ErrInvalidReferrer          = errors.New("allowed referrers must be domain names such as example.com")
+	ErrInvalidReferrerPolicy    = errors.New("noReferrer must be allow or deny")
+	ErrNoReferrerWithoutDomains = errors.New("noReferrer requires allowed referrers")
+
+	ErrInvalidQueryConflict   = errors.New("passthrough onConflict must be one of target, incoming, reject")
+	ErrTooManyPassthroughArgs = fmt.Errorf("passthrough allowedParams must contain at most %d entries", maxPassthroughParams)
+	ErrInvalidPassthroughArg  = errors.New("passthrough allowedParams must not be empty")
+	ErrQueryOptionsWithout    = errors.New("passthrough onConflict and allowedParams require query passthrough")
+
+	ErrCampaignValueTooLong = fmt.Errorf("campaign values must be at most %d characters", maxCampaignValueLen)
+	ErrInvalidCampaignValue = errors.New("campaign values must be printable and contain no placeholders other than {slug}")
+
+	ErrInvalidRedirectCode = errors.New("redirect code must be one of 302, 303, 307")
+
+	ErrInvalidCountdown = fmt.Errorf("interstitial countdown must be between 0 and %d seconds", maxCountdown)
+	ErrNoticeTooLong    = fmt.Errorf("interstitial notice must be at most %d characters", maxNoticeLen)
+
+	ErrInvalidFallbackReason   = errors.New("fallback reasons must be one of expired, exhausted, not_yet_valid, closed")
+	ErrFallbackOnWithoutTarget = errors.New("fallback reasons require a fallback target")
+
+	ErrSelfDestructTooShort      = fmt.Errorf("self-destruct timer must be at least %s", minTime)
+	ErrSelfDestructExceedsExpiry = errors.New("self-destruct timer must end before the link expires")
+
 	ErrAdminExpiresBeforeExpires = errors.New("admin expiration must be after normal expiration")
 
 	ErrAdminExpiresRequiresExpires = errors.New("admin_expires_at can only be updated if expires_at is also updated")
 	ErrUpdatedAtNotSet             = errors.New("updated_at timestamp must be set")
 
+	ErrInvalidDuration  = errors.New("duration must be ISO-8601 (e.g. P1DT12H), Go syntax (e.g. 36h), or a number of days")
+	ErrNegativeDuration = errors.New("duration must not be negative")
+
+	ErrConflictingExpiration = errors.New("only one of expiresAt, expiresIn, or validFor may be set")
+	ErrConflictingValidFrom  = errors.New("only one of validFrom or validFromIn may be set")
+
 	ErrUnrecognizedCharset = errors.New("unrecognized character set")
 	ErrInvalidSlugLen      = errors.New("slug length must be between 6 and 12 inclusive")
 	ErrGeneratingSlug      = errors.New("error generating the slug")
@@ -77,7 +145,7 @@ func (v *Validated) SetAdminToken() error {
 
 // SetPasswordHash generates and applies a password hash to the underlying link
 // once the password has been validated.
-func (v *Validated) SetPasswordHash(password *string) error {
+func (v *Validated) SetPasswordHash(ctx context.Context, password *string) error {
 	if password == nil || *password == "" {
 		return nil
 	}
@@ -86,7 +154,7 @@ func (v *Validated) SetPasswordHash(password *string) error {
 		return ErrPasswordTooLong
 	}
 
-	passwordHash, err := hashPassword(*password)
+	passwordHash, err := hashPassword(ctx, *password)
 	if err != nil {
 		return ErrHashingPassword
 	}
@@ -100,7 +168,7 @@ func (v *Validated) SetPasswordHash(password *string) error {
 // Do not assign a Slug, AdminToken, or Password before validating.
 // Use the provided SetSlug, SetAdminToken, and SetPasswordHash functions.
 func Validate(link *Link, now time.Time) (*Validated, error) {
-	if err := validateTarget(link.Target); err != nil {
+	if err := validateTargets(link.Target, link.Variants); err != nil {
 		return nil, err
 	}
 	if err := validateExpiresAt(link.ExpiresAt, now); err != nil {
@@ -115,6 +183,42 @@ func Validate(link *Link, now time.Time) (*Validated, error) {
 	if err := validateTimes(link.ValidFrom, link.ExpiresAt, link.AdminExpiresAt); err != nil {
 		return nil, err
 	}
+	if err := validateSelfDestruct(link.SelfDestructAfter, link.ValidFrom, link.ExpiresAt, now); err != nil {
+		return nil, err
+	}
+	if err := validateSchedule(link.Schedule); err != nil {
+		return nil, err
+	}
+	if err := validateFallback(link.FallbackTarget, link.FallbackOn); err != nil {
+		return nil, err
+	}
+	if err := validateRules(link.Rules); err != nil {
+		return nil, err
+	}
+	if err := validateCountries(link.AllowedCountries, link.BlockedCountries, link.CountryTargets); err != nil {
+		return nil, err
+	}
+	if err := validateCIDRs(link.AllowedCIDRs); err != nil {
+		return nil, err
+	}
+	if err := validateCIDRs(link.DeniedCIDRs); err != nil {
+		return nil, err
+	}
+	if err := validateReferrers(link.AllowedReferrers, link.NoReferrer); err != nil {
+		return nil, err
+	}
+	if err := validatePassthrough(link.Passthrough); err != nil {
+		return nil, err
+	}
+	if err := validateCampaign(link.Campaign); err != nil {
+		return nil, err
+	}
+	if err := validateRedirectCode(link.RedirectCode); err != nil {
+		return nil, err
+	}
+	if err := validateInterstitial(link.Interstitial); err != nil {
+		return nil, err
+	}
 
 	return &Validated{link: link}, nil
 }
@@ -135,7 +239,7 @@ func (v *ValidatedPatch) Patch() *PatchLink {
 
 // SetPasswordHash generates and applies a password hash to the underlying link
 // once the password has been validated.
-func (v *ValidatedPatch) SetPasswordHash(password *string) error {
+func (v *ValidatedPatch) SetPasswordHash(ctx context.Context, password *string) error {
 	if password == nil || *password == "" {
 		return nil
 	}
@@ -144,7 +248,7 @@ func (v *ValidatedPatch) SetPasswordHash(password *string) error {
 		return ErrPasswordTooLong
 	}
 
-	passwordHash, err := hashPassword(*password)
+	passwordHash, err := hashPassword(ctx, *password)
 	if err != nil {
 		return ErrHashingPassword
 	}
@@ -162,6 +266,35 @@ func (v *ValidatedPatch) SetPasswordHash(password *string) error {
 // Do not assign a Password before validating; use the provided SetPasswordHash
 // instead.
 func ValidatePatch(original *Link, patch *PatchLink, now time.Time) (*ValidatedPatch, error) {
+	target := original.Target
+	if patch.Target != nil {
+		target = *patch.Target
+	}
+
+	variants := original.Variants
+	if patch.Variants.Remove {
+		variants = nil
+	} else if patch.Variants.Value != nil {
+		merged := withHitCounts(*patch.Variants.Value, original.Variants)
+		patch.Variants.Value = &merged
+		variants = merged
+
+		// Switching to variants clears the single target.
+		if patch.Target == nil && original.Target != "" {
+			empty := ""
+			patch.Target = &empty
+			target = empty
+		}
+	} else if patch.Target != nil && *patch.Target != "" && len(original.Variants) != 0 {
+		// Switching to a single target removes the variants.
+		patch.Variants.Remove = true
+		variants = nil
+	}
+
+	if err := validateTargets(target, variants); err != nil {
+		return nil, err
+	}
+
 	if !patch.MaxHits.Remove && patch.MaxHits.Value != nil {
 		if err := validateMaxHits(patch.MaxHits.Value); err != nil {
 			return nil, err
@@ -207,6 +340,128 @@ func ValidatePatch(original *Link, patch *PatchLink, now time.Time) (*ValidatedP
 		return nil, err
 	}
 
+	var selfDestructAfter *Duration
+	if patch.SelfDestructAfter.Remove {
+		selfDestructAfter = nil
+	} else if patch.SelfDestructAfter.Value != nil {
+		selfDestructAfter = patch.SelfDestructAfter.Value
+	} else {
+		selfDestructAfter = original.SelfDestructAfter
+	}
+
+	if err := validateSelfDestruct(selfDestructAfter, validFrom, expiresAt, now); err != nil {
+		return nil, err
+	}
+
+	if !patch.Schedule.Remove && patch.Schedule.Value != nil {
+		if err := validateSchedule(patch.Schedule.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	fallbackTarget := original.FallbackTarget
+	if patch.FallbackTarget.Remove {
+		fallbackTarget = nil
+	} else if patch.FallbackTarget.Value != nil {
+		fallbackTarget = patch.FallbackTarget.Value
+	}
+
+	fallbackOn := original.FallbackOn
+	if patch.FallbackOn.Remove {
+		fallbackOn = nil
+	} else if patch.FallbackOn.Value != nil {
+		fallbackOn = *patch.FallbackOn.Value
+	}
+
+	if err := validateFallback(fallbackTarget, fallbackOn); err != nil {
+		return nil, err
+	}
+
+	if !patch.Rules.Remove && patch.Rules.Value != nil {
+		if err := validateRules(*patch.Rules.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	allowedCountries := original.AllowedCountries
+	if patch.AllowedCountries.Remove {
+		allowedCountries = nil
+	} else if patch.AllowedCountries.Value != nil {
+		allowedCountries = *patch.AllowedCountries.Value
+	}
+
+	blockedCountries := original.BlockedCountries
+	if patch.BlockedCountries.Remove {
+		blockedCountries = nil
+	} else if patch.BlockedCountries.Value != nil {
+		blockedCountries = *patch.BlockedCountries.Value
+	}
+
+	countryTargets := original.CountryTargets
+	if patch.CountryTargets.Remove {
+		countryTargets = nil
+	} else if patch.CountryTargets.Value != nil {
+		countryTargets = *patch.CountryTargets.Value
+	}
+
+	if err := validateCountries(allowedCountries, blockedCountries, countryTargets); err != nil {
+		return nil, err
+	}
+
+	if !patch.AllowedCIDRs.Remove && patch.AllowedCIDRs.Value != nil {
+		if err := validateCIDRs(*patch.AllowedCIDRs.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	if !patch.DeniedCIDRs.Remove && patch.DeniedCIDRs.Value != nil {
+		if err := validateCIDRs(*patch.DeniedCIDRs.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	allowedReferrers := original.AllowedReferrers
+	if patch.AllowedReferrers.Remove {
+		allowedReferrers = nil
+	} else if patch.AllowedReferrers.Value != nil {
+		allowedReferrers = *patch.AllowedReferrers.Value
+	}
+
+	noReferrer := original.NoReferrer
+	if patch.NoReferrer.Remove {
+		noReferrer = ""
+	} else if patch.NoReferrer.Value != nil {
+		noReferrer = *patch.NoReferrer.Value
+	}
+
+	if err := validateReferrers(allowedReferrers, noReferrer); err != nil {
+		return nil, err
+	}
+
+	if !patch.Passthrough.Remove && patch.Passthrough.Value != nil {
+		if err := validatePassthrough(patch.Passthrough.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	if !patch.Campaign.Remove && patch.Campaign.Value != nil {
+		if err := validateCampaign(patch.Campaign.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	if !patch.RedirectCode.Remove && patch.RedirectCode.Value != nil {
+		if err := validateRedirectCode(*patch.RedirectCode.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	if !patch.Interstitial.Remove && patch.Interstitial.Value != nil {
+		if err := validateInterstitial(patch.Interstitial.Value); err != nil {
+			return nil, err
+		}
+	}
+
 	if patch.UpdatedAt.IsZero() {
 		return nil, ErrUpdatedAtNotSet
 	}
@@ -215,11 +470,60 @@ func ValidatePatch(original *Link, patch *PatchLink, now time.Time) (*ValidatedP
 		return nil, err
 	}
 
+	patch.Previous = original.snapshot(now)
+
 	return &ValidatedPatch{patch: patch}, nil
 }
 
+// validateTargets ensures that exactly one of a single target or a list of
+// weighted variants is set, and that each is valid.
+func validateTargets(target string, variants []Variant) error {
+	if len(variants) == 0 {
+		return validateTarget(target)
+	}
+	if target != "" {
+		return ErrTargetAndVariants
+	}
+	return validateVariants(variants)
+}
+
+// validateVariants checks the number of variants and that each has a unique
+// name, a weight in range, and a valid target.
+func validateVariants(variants []Variant) error {
+	if len(variants) < minVariants || len(variants) > maxVariants {
+		return ErrVariantCount
+	}
+
+	names := make(map[string]bool, len(variants))
+	for _, v := range variants {
+		if !variantNamePattern.MatchString(v.Name) {
+			return ErrInvalidVariantName
+		}
+		if names[v.Name] {
+			return ErrDuplicateVariantName
+		}
+		names[v.Name] = true
+
+		if v.Weight < 1 || v.Weight > maxVariantWeight {
+			return ErrInvalidVariantWeight
+		}
+		if err := validateTarget(v.Target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // validateTarget ensures the target string is a valid HTTP/HTTPS URL with a host.
+// Targets may be templates whose variables are validated by validateTemplate.
 func validateTarget(target string) error {
+	if IsTemplate(target) {
+		if err := validateTemplate(target); err != nil {
+			return err
+		}
+		target = placeholderPattern.ReplaceAllString(target, "")
+	}
+
 	parsed, err := url.ParseRequestURI(target)
 	if err != nil {
 		return ErrInvalidURLFormat
@@ -234,6 +538,50 @@ func validateTarget(target string) error {
 	return nil
 }
 
+// validateTemplate checks that every variable in a target template is known
+// and well-formed, and that none can change the scheme or host.
+func validateTemplate(target string) error {
+	matches := placeholderPattern.FindAllStringSubmatchIndex(target, -1)
+	if IsTemplate(placeholderPattern.ReplaceAllString(target, "")) {
+		return ErrInvalidTemplate
+	}
+
+	schemeEnd := strings.Index(target, "://")
+	if schemeEnd < 0 {
+		return ErrInvalidURLFormat
+	}
+	hostEnd := strings.IndexAny(target[schemeEnd+3:], "/?#")
+	if hostEnd < 0 || matches[0][0] < schemeEnd+3+hostEnd {
+		return ErrTemplateInAuthority
+	}
+
+	for _, m := range matches {
+		name := target[m[2]:m[3]]
+		hasKey := m[4] >= 0
+		switch name {
+		case varQuery:
+			if !hasKey {
+				return ErrInvalidTemplate
+			}
+		case varPath:
+			if !hasKey {
+				return ErrInvalidTemplate
+			}
+			n, err := strconv.Atoi(target[m[4]:m[5]])
+			if err != nil || n < 1 || n > maxPathVar {
+				return ErrInvalidTemplate
+			}
+		case varLang, varCountry, varTimestamp:
+			if hasKey {
+				return ErrInvalidTemplate
+			}
+		default:
+			return ErrInvalidTemplate
+		}
+	}
+	return nil
+}
+
 // validateExpiresAt checks that the expiration time is at least 1 minute in the future,
 // but no more than 30 days ahead from the reference time 'now'.
 func validateExpiresAt(expiresAt time.Time, now time.Time) error {
@@ -272,6 +620,279 @@ func validateTimes(validFrom *time.Time, expiresAt, adminExpiresAt time.Time) er
 	return nil
 }
 
+// validateSelfDestruct checks that the self-destruct timer, if set, is at
+// least 1 minute long and can run out before ExpiresAt when started at the
+// earliest possible first hit.
+func validateSelfDestruct(selfDestructAfter *Duration, validFrom *time.Time, expiresAt time.Time, now time.Time) error {
+	if selfDestructAfter == nil {
+		return nil
+	}
+	d := time.Duration(*selfDestructAfter)
+	if d < minTime {
+		return ErrSelfDestructTooShort
+	}
+	start := now
+	if validFrom != nil && validFrom.After(now) {
+		start = *validFrom
+	}
+	if !start.Add(d).Before(expiresAt) {
+		return ErrSelfDestructExceedsExpiry
+	}
+	return nil
+}
+
+// validateSchedule checks that the schedule, if set, has a valid IANA time zone,
+// well-formed weekly windows, and well-formed exception dates.
+func validateSchedule(schedule *Schedule) error {
+	if schedule == nil {
+		return nil
+	}
+
+	if schedule.TimeZone == "" {
+		return ErrScheduleMissingTimeZone
+	}
+	if _, err := loadLocation(schedule.TimeZone); err != nil {
+		return ErrScheduleInvalidTimeZone
+	}
+
+	if len(schedule.Windows) == 0 || len(schedule.Windows) > maxScheduleWindows {
+		return ErrScheduleNoWindows
+	}
+	for _, w := range schedule.Windows {
+		if len(w.Days) == 0 {
+			return ErrScheduleWindowNoDays
+		}
+		for _, d := range w.Days {
+			if _, ok := weekdays[strings.ToLower(d)]; !ok {
+				return ErrScheduleInvalidDay
+			}
+		}
+		start, err := parseClock(w.Start)
+		if err != nil {
+			return err
+		}
+		end, err := parseClock(w.End)
+		if err != nil {
+			return err
+		}
+		if start >= end {
+			return ErrScheduleInvalidWindow
+		}
+	}
+
+	if len(schedule.Exceptions) > maxScheduleExceptions {
+		return ErrScheduleTooManyExcepts
+	}
+	for _, date := range schedule.Exceptions {
+		if _, err := time.Parse(dateLayout, date); err != nil {
+			return ErrScheduleInvalidExcept
+		}
+	}
+
+	return nil
+}
+
+// validateFallback checks that the fallback target, if set, is a valid URL and
+// that every fallback reason is an unavailability status.
+func validateFallback(target *string, reasons []Status) error {
+	if target == nil {
+		if len(reasons) != 0 {
+			return ErrFallbackOnWithoutTarget
+		}
+		return nil
+	}
+	if err := validateTarget(*target); err != nil {
+		return err
+	}
+	for _, reason := range reasons {
+		switch reason {
+		case StatusExpired, StatusExhausted, StatusNotYetValid, StatusClosed:
+		default:
+			return ErrInvalidFallbackReason
+		}
+	}
+	return nil
+}
+
+// validateRules checks the number of rules and that each has at least one
+// well-formed condition and a valid target.
+func validateRules(rules []Rule) error {
+	if len(rules) > maxRules {
+		return ErrTooManyRules
+	}
+
+	for _, rule := range rules {
+		if !rule.hasConditions() {
+			return ErrRuleWithoutCondition
+		}
+		if len(rule.Devices) > maxRuleValues || len(rule.Languages) > maxRuleValues ||
+			len(rule.Referrers) > maxRuleValues || len(rule.Query) > maxRuleValues {
+			return ErrTooManyRuleValues
+		}
+		for _, device := range rule.Devices {
+			switch device {
+			case DeviceIOS, DeviceAndroid, DeviceDesktop:
+			default:
+				return ErrInvalidRuleDevice
+			}
+		}
+		for _, lang := range rule.Languages {
+			if !languagePattern.MatchString(lang) {
+				return ErrInvalidRuleLanguage
+			}
+		}
+		for _, domain := range rule.Referrers {
+			if !domainPattern.MatchString(domain) {
+				return ErrInvalidRuleReferrer
+			}
+		}
+		for key := range rule.Query {
+			if key == "" {
+				return ErrInvalidRuleQuery
+			}
+		}
+		if err := validateTarget(rule.Target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateCountries checks that at most one of the allowed and blocked lists
+// is set, that every country code is well-formed, and that every country
+// target is a valid URL.
+func validateCountries(allowed, blocked []string, targets map[string]string) error {
+	if len(allowed) != 0 && len(blocked) != 0 {
+		return ErrAllowedAndBlockedCountries
+	}
+	if len(allowed) > maxCountries || len(blocked) > maxCountries || len(targets) > maxCountries {
+		return ErrTooManyCountries
+	}
+
+	for _, country := range slices.Concat(allowed, blocked) {
+		if !countryPattern.MatchString(country) {
+			return ErrInvalidCountry
+		}
+	}
+	for country, target := range targets {
+		if !countryPattern.MatchString(country) {
+			return ErrInvalidCountry
+		}
+		if err := validateTarget(target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateCIDRs checks the number of networks in a list and that each is a
+// valid CIDR or IP address.
+func validateCIDRs(cidrs []string) error {
+	if len(cidrs) > maxCIDRs {
+		return ErrTooManyCIDRs
+	}
+	_, err := ParseCIDRs(cidrs)
+	return err
+}
+
+// validateReferrers checks that the allowed referrers are domain names and that
+// the missing-referrer policy, if set, is valid and has domains to apply to.
+func validateReferrers(domains []string, policy ReferrerPolicy) error {
+	if len(domains) > maxReferrers {
+		return ErrTooManyReferrers
+	}
+	for _, domain := range domains {
+		if !domainPattern.MatchString(domain) {
+			return ErrInvalidReferrer
+		}
+	}
+
+	switch policy {
+	case "":
+		return nil
+	case ReferrerAllow, ReferrerDeny:
+		if len(domains) == 0 {
+			return ErrNoReferrerWithoutDomains
+		}
+		return nil
+	default:
+		return ErrInvalidReferrerPolicy
+	}
+}
+
+// validatePassthrough checks that the conflict policy is known and that query
+// options are only set when the query is passed through.
+func validatePassthrough(p *Passthrough) error {
+	if p == nil {
+		return nil
+	}
+
+	switch p.OnConflict {
+	case "", KeepTarget, KeepIncoming, RejectQuery:
+	default:
+		return ErrInvalidQueryConflict
+	}
+
+	if len(p.AllowedParams) > maxPassthroughParams {
+		return ErrTooManyPassthroughArgs
+	}
+	for _, param := range p.AllowedParams {
+		if param == "" {
+			return ErrInvalidPassthroughArg
+		}
+	}
+
+	if !p.Query && (p.OnConflict != "" || len(p.AllowedParams) != 0) {
+		return ErrQueryOptionsWithout
+	}
+	return nil
+}
+
+// validateCampaign checks that every campaign value is short, printable, and
+// only uses the {slug} placeholder.
+func validateCampaign(c *Campaign) error {
+	if c == nil {
+		return nil
+	}
+	for _, p := range campaignParams {
+		value := *p.field(c)
+		if len(value) > maxCampaignValueLen {
+			return ErrCampaignValueTooLong
+		}
+		if strings.ContainsFunc(value, func(r rune) bool { return !unicode.IsPrint(r) }) {
+			return ErrInvalidCampaignValue
+		}
+		if strings.ContainsAny(strings.ReplaceAll(value, slugPlaceholder, ""), "{}") {
+			return ErrInvalidCampaignValue
+		}
+	}
+	return nil
+}
+
+// validateRedirectCode checks that the redirect code, if set, is a redirect
+// status a link may use.
+func validateRedirectCode(code int) error {
+	if code != 0 && !IsRedirectCode(code) {
+		return ErrInvalidRedirectCode
+	}
+	return nil
+}
+
+// validateInterstitial checks the countdown range and notice length of the
+// interstitial page, if set.
+func validateInterstitial(i *Interstitial) error {
+	if i == nil {
+		return nil
+	}
+	if i.Countdown < 0 || i.Countdown > maxCountdown {
+		return ErrInvalidCountdown
+	}
+	if len(i.Notice) > maxNoticeLen {
+		return ErrNoticeTooLong
+	}
+	return nil
+}
+
 // validateMaxHits verifies that maxHits is non-negative if specified (nil means no limit).
 func validateMaxHits(maxHits *int) error {
 	if maxHits == nil {
@@ -0,0 +1,76 @@
+package link
+
+import (
+	"math/rand/v2"
+	"regexp"
+)
+
+const (
+	// minVariants is the minimum number of variants in a split link.
+	minVariants = 2
+
+	// maxVariants is the maximum number of variants in a split link.
+	maxVariants = 10
+
+	// maxVariantWeight is the largest weight a single variant may have.
+	maxVariantWeight = 1000
+)
+
+// variantNamePattern restricts variant names to short cookie-safe identifiers.
+var variantNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// Variant is one of several weighted targets a split link may redirect to.
+type Variant struct {
+	Name     string `bson:"name" json:"name"`          // Unique identifier within the link, e.g. "A"
+	Target   string `bson:"target" json:"target"`      // Destination URL
+	Weight   int    `bson:"weight" json:"weight"`      // Relative share of traffic
+	HitCount int    `bson:"hit_count" json:"hitCount"` // Number of redirects to this variant so far
+}
+
+// VariantByName returns the variant with the given name, or nil if there is
+// none.
+func (l *Link) VariantByName(name string) *Variant {
+	for i := range l.Variants {
+		if l.Variants[i].Name == name {
+			return &l.Variants[i]
+		}
+	}
+	return nil
+}
+
+// PickVariant chooses a variant at random in proportion to the weights.
+// Returns nil if the link has no variants.
+func (l *Link) PickVariant() *Variant {
+	total := 0
+	for _, v := range l.Variants {
+		total += v.Weight
+	}
+	if total <= 0 {
+		return nil
+	}
+
+	n := rand.IntN(total)
+	for i := range l.Variants {
+		n -= l.Variants[i].Weight
+		if n < 0 {
+			return &l.Variants[i]
+		}
+	}
+	return nil
+}
+
+// withHitCounts returns a copy of variants with hit counts carried over from
+// the variants of the same name in previous.
+func withHitCounts(variants, previous []Variant) []Variant {
+	counts := make(map[string]int, len(previous))
+	for _, v := range previous {
+		counts[v.Name] = v.HitCount
+	}
+
+	result := make([]Variant, len(variants))
+	for i, v := range variants {
+		v.HitCount = counts[v.Name]
+		result[i] = v
+	}
+	return result
+}
@@ -2,83 +2,145 @@ package main
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
-	"time"
+	"syscall"
+	_ "time/tzdata"
 
+	"github.com/lucasmcclean/limitlink/geoip"
+	"github.com/lucasmcclean/limitlink/lifecycle"
+	"github.com/lucasmcclean/limitlink/logging"
 	"github.com/lucasmcclean/limitlink/mongo"
 	"github.com/lucasmcclean/limitlink/server"
+	"github.com/lucasmcclean/limitlink/tracing"
 )
 
 func main() {
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
-	log.Println("starting limitlink...")
+	logger, err := logging.NewFromEnv()
+	if err != nil {
+		slog.Error("error configuring logger", slog.Any("error", err))
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+
+	slog.Info("starting limitlink...")
+
+	lc, err := lifecycle.NewFromEnv()
+	if err != nil {
+		slog.Error("error configuring shutdown", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	shutdownTracing, err := tracing.Setup(ctx)
+	if err != nil {
+		slog.Error("error configuring tracing", slog.Any("error", err))
+		os.Exit(1)
+	}
 
 	store, err := mongo.New(ctx)
 	if err != nil {
-		log.Fatalf("error connecting to the database: %v\n", err)
+		slog.Error("error connecting to the database", slog.Any("error", err))
+		os.Exit(1)
 	}
 
 	links, err := store.Links(ctx)
 	if err != nil {
-		log.Fatalf("error preparing links collection: %v\n", err)
+		slog.Error("error preparing links collection", slog.Any("error", err))
+		os.Exit(1)
 	}
 
-	srv := server.New(links)
+	geo, err := geoip.NewFromEnv()
+	if err != nil {
+		slog.Error("error opening GeoIP database", slog.Any("error", err))
+		os.Exit(1)
+	}
 
-	serverErr := make(chan error, 1)
+	cfg, err := server.ConfigFromEnv()
+	if err != nil {
+		slog.Error("error configuring server", slog.Any("error", err))
+		os.Exit(1)
+	}
+	if geo != nil {
+		cfg.Countries = geo
+		go reloadOnHangup(ctx, geo)
+	}
+	health := server.NewHealth(store)
+	srv := server.New(links, health, cfg)
+	adminSrv := server.NewAdmin(cfg)
+
+	lc.OnNotReady(health.SetNotReady)
+	lc.AddServer("http server", srv)
+	if adminSrv != nil {
+		lc.AddServer("admin server", adminSrv)
+	}
+	lc.AddFlusher("traces", shutdownTracing)
+	lc.AddCloser("database connection", store.Close)
+	if geo != nil {
+		lc.AddCloser("GeoIP database", geo.Close)
+	}
+
+	serverErr := make(chan error, 2)
 	go func() {
-		log.Printf("listening and serving on: %s\n", srv.Addr)
+		slog.Info("listening and serving", slog.String("addr", srv.Addr))
 		err := srv.ListenAndServe()
 		if err != nil && err != http.ErrServerClosed {
 			serverErr <- err
 		}
 	}()
 
+	if adminSrv != nil {
+		go func() {
+			slog.Info("serving metrics", slog.String("addr", adminSrv.Addr))
+			err := adminSrv.ListenAndServe()
+			if err != nil && err != http.ErrServerClosed {
+				serverErr <- err
+			}
+		}()
+	}
+
 	select {
 	case <-ctx.Done():
-		log.Println("received shutdown signal")
-		log.Println("starting shutdown...")
+		// Restore default signal handling so a second signal forces exit.
+		cancel()
 
-		if !shutdown(srv, store) {
+		slog.Info("received shutdown signal")
+		slog.Info("starting shutdown...")
+
+		if err := lc.Shutdown(); err != nil {
 			os.Exit(1)
 		}
+		slog.Info("shutdown complete")
 
 	case err := <-serverErr:
-		shutdownCtx, cancelShutdown := context.WithTimeout(ctx, 1*time.Second)
-		defer cancelShutdown()
-
-		closeErr := store.Close(shutdownCtx)
-		if closeErr != nil {
-			log.Printf("error closing store after server failure: %v", closeErr)
-		}
+		slog.Error("error listening and serving", slog.Any("error", err))
 
-		log.Fatalf("error listening and serving: %v\n", err)
+		_ = lc.Close()
+		os.Exit(1)
 	}
 }
 
-func shutdown(srv *http.Server, store *mongo.Store) (ok bool) {
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	err := store.Close(shutdownCtx)
-	if err != nil {
-		log.Printf("error closing database connection: %v\n", err)
-		ok = false
-	} else {
-		log.Println("database connection closed successfully")
-	}
-
-	if err = srv.Shutdown(shutdownCtx); err != nil {
-		log.Printf("error shutting down http server: %v\n", err)
-		ok = false
-	} else {
-		log.Println("http server shut down successfully")
+// reloadOnHangup reloads the GeoIP database whenever the process receives
+// SIGHUP, so an updated database file can be swapped in without a restart.
+func reloadOnHangup(ctx context.Context, geo *geoip.DB) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			if err := geo.Reload(); err != nil {
+				slog.Error("error reloading GeoIP database", slog.Any("error", err))
+				continue
+			}
+			slog.Info("reloaded GeoIP database")
+		}
 	}
-
-	return ok
 }
@@ -0,0 +1,160 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "limitlink"
+
+// Redirect outcomes recorded by ObserveRedirect.
+const (
+	RedirectOK           = "ok"
+	RedirectFallback     = "fallback"
+	RedirectNotFound     = "not_found"
+	RedirectNotYetValid  = "not_yet_valid"
+	RedirectExpired      = "expired"
+	RedirectExhausted    = "exhausted"
+	RedirectClosed       = "closed"
+	RedirectPassword     = "password"
+	RedirectGeoBlocked   = "geo_blocked"
+	RedirectIPDenied     = "ip_denied"
+	RedirectReferrer     = "referrer_blocked"
+	RedirectHead         = "head"
+	RedirectInterstitial = "interstitial"
+	RedirectDisabled     = "disabled"
+	RedirectError        = "error"
+)
+
+var registry = prometheus.NewRegistry()
+
+var (
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests handled, by route, method and status.",
+	}, []string{"route", "method", "status"})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests, by route, method and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	requestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "Number of HTTP requests currently being handled.",
+	})
+
+	redirectsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redirects_total",
+		Help:      "Number of redirect attempts, by outcome.",
+	}, []string{"outcome"})
+
+	linksCreatedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "links_created_total",
+		Help:      "Number of links successfully created.",
+	})
+
+	repoDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "repository_operation_duration_seconds",
+		Help:      "Latency of link repository operations, by operation.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	repoErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "repository_operation_errors_total",
+		Help:      "Number of failed link repository operations, by operation.",
+	}, []string{"operation"})
+
+	idempotencyEvictionsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "idempotency_evictions_total",
+		Help:      "Number of idempotency keys forgotten early because the cache was full.",
+	})
+
+	passwordDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "password_verification_duration_seconds",
+		Help:      "Time spent verifying link passwords with bcrypt.",
+		Buckets:   []float64{.01, .025, .05, .1, .25, .5, 1, 2.5},
+	})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requestsTotal,
+		requestDuration,
+		requestsInFlight,
+		redirectsTotal,
+		linksCreatedTotal,
+		repoDuration,
+		repoErrorsTotal,
+		idempotencyEvictionsTotal,
+		passwordDuration,
+	)
+}
+
+// Handler returns an http.Handler serving all metrics in the Prometheus text
+// exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// RequestStarted marks the start of an HTTP request and returns a function
+// that records its completion with the given status code.
+func RequestStarted(route, method string) func(status int) {
+	start := time.Now()
+	requestsInFlight.Inc()
+
+	return func(status int) {
+		requestsInFlight.Dec()
+
+		code := strconv.Itoa(status)
+		requestsTotal.WithLabelValues(route, method, code).Inc()
+		requestDuration.WithLabelValues(route, method, code).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveRedirect records the outcome of a redirect attempt.
+func ObserveRedirect(outcome string) {
+	redirectsTotal.WithLabelValues(outcome).Inc()
+}
+
+// IncLinksCreated records a successfully created link.
+func IncLinksCreated() {
+	linksCreatedTotal.Inc()
+}
+
+// IncIdempotencyEvictions records an idempotency key evicted to make room for
+// a new one.
+func IncIdempotencyEvictions() {
+	idempotencyEvictionsTotal.Inc()
+}
+
+// ObservePasswordCheck records the time taken by a bcrypt password verification.
+func ObservePasswordCheck(d time.Duration) {
+	passwordDuration.Observe(d.Seconds())
+}
+
+// observeRepo records the latency and, if err is non-nil, the failure of a
+// repository operation.
+func observeRepo(operation string, start time.Time, err error) {
+	repoDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		repoErrorsTotal.WithLabelValues(operation).Inc()
+	}
+}
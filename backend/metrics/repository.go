@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/lucasmcclean/limitlink/link"
+)
+
+// Repository wraps a link.Repository and records the latency and errors of
+// every operation.
+type Repository struct {
+	next link.Repository
+}
+
+// NewRepository returns a Repository that instruments next.
+func NewRepository(next link.Repository) *Repository {
+	return &Repository{next: next}
+}
+
+// Create inserts a new link into the wrapped repository.
+func (r *Repository) Create(ctx context.Context, vLink *link.Validated) (err error) {
+	defer func(start time.Time) { observeRepo("create", start, err) }(time.Now())
+	return r.next.Create(ctx, vLink)
+}
+
+// GetBySlug retrieves a link by its public slug from the wrapped repository.
+func (r *Repository) GetBySlug(ctx context.Context, slug string) (lnk *link.Link, err error) {
+	defer func(start time.Time) { observeRepo("get_by_slug", start, err) }(time.Now())
+	return r.next.GetBySlug(ctx, slug)
+}
+
+// IncBySlug increments the hit count for the given slug in the wrapped repository.
+func (r *Repository) IncBySlug(ctx context.Context, slug string) (err error) {
+	defer func(start time.Time) { observeRepo("inc_by_slug", start, err) }(time.Now())
+	return r.next.IncBySlug(ctx, slug)
+}
+
+// IncVariantBySlug increments the hit counts for the given slug and variant in the wrapped repository.
+func (r *Repository) IncVariantBySlug(ctx context.Context, slug, variant string) (err error) {
+	defer func(start time.Time) { observeRepo("inc_variant_by_slug", start, err) }(time.Now())
+	return r.next.IncVariantBySlug(ctx, slug, variant)
+}
+
+// IncFallbackBySlug increments the fallback hit count for the given slug in the wrapped repository.
+func (r *Repository) IncFallbackBySlug(ctx context.Context, slug string) (err error) {
+	defer func(start time.Time) { observeRepo("inc_fallback_by_slug", start, err) }(time.Now())
+	return r.next.IncFallbackBySlug(ctx, slug)
+}
+
+// GetByToken retrieves a link by its admin token from the wrapped repository.
+func (r *Repository) GetByToken(ctx context.Context, token string) (lnk *link.Link, err error) {
+	defer func(start time.Time) { observeRepo("get_by_token", start, err) }(time.Now())
+	return r.next.GetByToken(ctx, token)
+}
+
+// DeleteByToken removes a link by its admin token from the wrapped repository.
+func (r *Repository) DeleteByToken(ctx context.Context, token string) (err error) {
+	defer func(start time.Time) { observeRepo("delete_by_token", start, err) }(time.Now())
+	return r.next.DeleteByToken(ctx, token)
+}
+
+// PatchByToken updates a link by its admin token in the wrapped repository.
+func (r *Repository) PatchByToken(ctx context.Context, token string, patch *link.ValidatedPatch) (err error) {
+	defer func(start time.Time) { observeRepo("patch_by_token", start, err) }(time.Now())
+	return r.next.PatchByToken(ctx, token, patch)
+}
+
+// ListRevisionsByToken returns the recorded revisions of a link from the wrapped repository.
+func (r *Repository) ListRevisionsByToken(ctx context.Context, token string) (revs []link.Revision, err error) {
+	defer func(start time.Time) { observeRepo("list_revisions_by_token", start, err) }(time.Now())
+	return r.next.ListRevisionsByToken(ctx, token)
+}
+
+// GetRevisionByToken retrieves a single revision of a link from the wrapped repository.
+func (r *Repository) GetRevisionByToken(ctx context.Context, token string, number int) (rev *link.Revision, err error) {
+	defer func(start time.Time) { observeRepo("get_revision_by_token", start, err) }(time.Now())
+	return r.next.GetRevisionByToken(ctx, token, number)
+}
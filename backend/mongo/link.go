@@ -4,7 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 
 	"github.com/lucasmcclean/limitlink/link"
 	"go.mongodb.org/mongo-driver/bson"
@@ -12,6 +12,19 @@ import (
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
+const (
+	// linksCollection is the name of the collection storing links.
+	linksCollection = "links"
+
+	ttlIndexName        = "adminExpiresAtTTL"
+	slugIndexName       = "slugUnique"
+	adminTokenIndexName = "adminTokenUnique"
+)
+
+// requiredIndexes lists the indexes that must exist on the links collection
+// before the store is considered ready.
+var requiredIndexes = []string{ttlIndexName, slugIndexName, adminTokenIndexName}
+
 // Links wraps the "links" collection and implements the link.Repository
 // interface.
 type Links struct {
@@ -21,12 +34,16 @@ type Links struct {
 // Links returns a new Links wrapper for the store's "links" collection.
 func (store *Store) Links(ctx context.Context) (*Links, error) {
 	links := &Links{
-		store.db.Collection("links"),
+		store.db.Collection(linksCollection),
 	}
 	err := links.EnsureTTLIndex(ctx)
 	if err != nil {
 		return nil, err
 	}
+	err = links.EnsureUniqueIndexes(ctx)
+	if err != nil {
+		return nil, err
+	}
 	return links, nil
 }
 
@@ -37,7 +54,7 @@ func (l *Links) EnsureTTLIndex(ctx context.Context) error {
 		Keys: bson.M{"admin_expires_at": 1},
 		Options: options.Index().
 			SetExpireAfterSeconds(0).
-			SetName("adminExpiresAtTTL"),
+			SetName(ttlIndexName),
 	}
 
 	_, err := l.collection.Indexes().CreateOne(ctx, index)
@@ -45,7 +62,30 @@ func (l *Links) EnsureTTLIndex(ctx context.Context) error {
 		return fmt.Errorf("failed to create TTL index: %w", err)
 	}
 
-	log.Println("TTL index on 'admin_expires_at' ensured")
+	slog.InfoContext(ctx, "TTL index ensured", slog.String("field", "admin_expires_at"))
+	return nil
+}
+
+// EnsureUniqueIndexes sets up unique indexes on the "slug" and "admin_token"
+// fields so lookups are fast and collisions are rejected by the database.
+func (l *Links) EnsureUniqueIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.M{"slug": 1},
+			Options: options.Index().SetUnique(true).SetName(slugIndexName),
+		},
+		{
+			Keys:    bson.M{"admin_token": 1},
+			Options: options.Index().SetUnique(true).SetName(adminTokenIndexName),
+		},
+	}
+
+	_, err := l.collection.Indexes().CreateMany(ctx, indexes)
+	if err != nil {
+		return fmt.Errorf("failed to create unique indexes: %w", err)
+	}
+
+	slog.InfoContext(ctx, "unique indexes ensured", slog.String("fields", "slug, admin_token"))
 	return nil
 }
 
@@ -55,11 +95,14 @@ func (l *Links) Create(ctx context.Context, vLink *link.Validated) error {
 	return err
 }
 
+// withoutRevisions excludes the revision history when reading link documents.
+var withoutRevisions = options.FindOne().SetProjection(bson.M{"revisions": 0})
+
 // GetBySlug retrieves a link document by its slug.
 // Returns a nil Link if one is not found.
 func (l *Links) GetBySlug(ctx context.Context, slug string) (*link.Link, error) {
 	var result link.Link
-	err := l.collection.FindOne(ctx, bson.M{"slug": slug}).Decode(&result)
+	err := l.collection.FindOne(ctx, bson.M{"slug": slug}, withoutRevisions).Decode(&result)
 	if errors.Is(err, mongo.ErrNoDocuments) {
 		return nil, nil
 	}
@@ -67,12 +110,66 @@ func (l *Links) GetBySlug(ctx context.Context, slug string) (*link.Link, error)
 	return &result, err
 }
 
-// IncBySlug atomically increments the hit counter for the link with the given slug.
+// IncBySlug atomically increments the hit counter for the link with the given
+// slug and records the time of the first hit if it is not already set.
 func (l *Links) IncBySlug(ctx context.Context, slug string) error {
 	_, err := l.collection.UpdateOne(
 		ctx,
 		bson.M{"slug": slug},
-		bson.M{"$inc": bson.M{"hit_count": 1}},
+		bson.A{
+			bson.M{"$set": bson.M{
+				"hit_count":    bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$hit_count", 0}}, 1}},
+				"first_hit_at": bson.M{"$ifNull": bson.A{"$first_hit_at", "$$NOW"}},
+			}},
+		},
+	)
+	return err
+}
+
+// IncVariantBySlug atomically increments the hit counters for the link with
+// the given slug and its named variant, and records the time of the first hit
+// if it is not already set. The update is a pipeline, like IncBySlug, so the
+// first hit is timed by the database clock; the variant is matched with $map
+// because pipelines can't use the positional operator.
+//
+// The link is matched by slug alone, so its hit is still counted if a PATCH
+// renamed or removed the variant since it was chosen.
+func (l *Links) IncVariantBySlug(ctx context.Context, slug, variant string) error {
+	_, err := l.collection.UpdateOne(
+		ctx,
+		bson.M{"slug": slug},
+		bson.A{
+			bson.M{"$set": bson.M{
+				"hit_count":    bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$hit_count", 0}}, 1}},
+				"first_hit_at": bson.M{"$ifNull": bson.A{"$first_hit_at", "$$NOW"}},
+				"variants": bson.M{"$cond": bson.A{
+					bson.M{"$isArray": "$variants"},
+					bson.M{"$map": bson.M{
+						"input": "$variants",
+						"as":    "v",
+						"in": bson.M{"$cond": bson.A{
+							bson.M{"$eq": bson.A{"$$v.name", bson.M{"$literal": variant}}},
+							bson.M{"$mergeObjects": bson.A{"$$v", bson.M{
+								"hit_count": bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$$v.hit_count", 0}}, 1}},
+							}}},
+							"$$v",
+						}},
+					}},
+					"$$REMOVE",
+				}},
+			}},
+		},
+	)
+	return err
+}
+
+// IncFallbackBySlug atomically increments the fallback hit counter for the
+// link with the given slug.
+func (l *Links) IncFallbackBySlug(ctx context.Context, slug string) error {
+	_, err := l.collection.UpdateOne(
+		ctx,
+		bson.M{"slug": slug},
+		bson.M{"$inc": bson.M{"fallback_hit_count": 1}},
 	)
 	return err
 }
@@ -80,7 +177,7 @@ func (l *Links) IncBySlug(ctx context.Context, slug string) error {
 // GetByToken retrieves a link document by its admin token.
 func (l *Links) GetByToken(ctx context.Context, token string) (*link.Link, error) {
 	var result link.Link
-	err := l.collection.FindOne(ctx, bson.M{"admin_token": token}).Decode(&result)
+	err := l.collection.FindOne(ctx, bson.M{"admin_token": token}, withoutRevisions).Decode(&result)
 	if errors.Is(err, mongo.ErrNoDocuments) {
 		return nil, nil
 	}
@@ -94,18 +191,31 @@ func (l *Links) DeleteByToken(ctx context.Context, token string) error {
 }
 
 // PatchByToken updates a link document by its admin token using a PatchLink struct.
+// The replaced state is pushed onto the document's revision history, which is
+// capped at link.MaxRevisions entries. The update only applies if the document
+// is still at the revision the patch was validated against.
 func (l *Links) PatchByToken(ctx context.Context, token string, vPatch *link.ValidatedPatch) error {
 	patch := vPatch.Patch()
+	previous := patch.Previous
 
 	setFields := bson.M{
 		"updated_at": patch.UpdatedAt,
+		"revision":   previous.Number + 1,
 	}
 	unsetFields := bson.M{}
 
+	if patch.Target != nil {
+		setFields["target"] = *patch.Target
+	}
+
 	if patch.ExpiresAt != nil {
 		setFields["expires_at"] = *patch.ExpiresAt
 	}
 
+	if patch.AdminExpiresAt != nil {
+		setFields["admin_expires_at"] = *patch.AdminExpiresAt
+	}
+
 	if patch.MaxHits.Remove {
 		unsetFields["max_hits"] = ""
 	} else if patch.MaxHits.Value != nil {
@@ -118,24 +228,195 @@ func (l *Links) PatchByToken(ctx context.Context, token string, vPatch *link.Val
 		setFields["valid_from"] = *patch.ValidFrom.Value
 	}
 
+	if patch.Schedule.Remove {
+		unsetFields["schedule"] = ""
+	} else if patch.Schedule.Value != nil {
+		setFields["schedule"] = *patch.Schedule.Value
+	}
+
+	if patch.Variants.Remove {
+		unsetFields["variants"] = ""
+	} else if patch.Variants.Value != nil {
+		setFields["variants"] = *patch.Variants.Value
+	}
+
+	if patch.StickyVariants != nil {
+		setFields["sticky_variants"] = *patch.StickyVariants
+	}
+
+	if patch.Rules.Remove {
+		unsetFields["rules"] = ""
+	} else if patch.Rules.Value != nil {
+		setFields["rules"] = *patch.Rules.Value
+	}
+
+	if patch.AllowedCountries.Remove {
+		unsetFields["allowed_countries"] = ""
+	} else if patch.AllowedCountries.Value != nil {
+		setFields["allowed_countries"] = *patch.AllowedCountries.Value
+	}
+
+	if patch.BlockedCountries.Remove {
+		unsetFields["blocked_countries"] = ""
+	} else if patch.BlockedCountries.Value != nil {
+		setFields["blocked_countries"] = *patch.BlockedCountries.Value
+	}
+
+	if patch.AllowedCIDRs.Remove {
+		unsetFields["allowed_cidrs"] = ""
+	} else if patch.AllowedCIDRs.Value != nil {
+		setFields["allowed_cidrs"] = *patch.AllowedCIDRs.Value
+	}
+
+	if patch.DeniedCIDRs.Remove {
+		unsetFields["denied_cidrs"] = ""
+	} else if patch.DeniedCIDRs.Value != nil {
+		setFields["denied_cidrs"] = *patch.DeniedCIDRs.Value
+	}
+
+	if patch.AllowedReferrers.Remove {
+		unsetFields["allowed_referrers"] = ""
+	} else if patch.AllowedReferrers.Value != nil {
+		setFields["allowed_referrers"] = *patch.AllowedReferrers.Value
+	}
+
+	if patch.NoReferrer.Remove {
+		unsetFields["no_referrer"] = ""
+	} else if patch.NoReferrer.Value != nil {
+		setFields["no_referrer"] = *patch.NoReferrer.Value
+	}
+
+	if patch.Passthrough.Remove {
+		unsetFields["passthrough"] = ""
+	} else if patch.Passthrough.Value != nil {
+		setFields["passthrough"] = *patch.Passthrough.Value
+	}
+
+	if patch.Campaign.Remove {
+		unsetFields["campaign"] = ""
+	} else if patch.Campaign.Value != nil {
+		setFields["campaign"] = *patch.Campaign.Value
+	}
+
+	if patch.RedirectCode.Remove {
+		unsetFields["redirect_code"] = ""
+	} else if patch.RedirectCode.Value != nil {
+		setFields["redirect_code"] = *patch.RedirectCode.Value
+	}
+
+	if patch.Interstitial.Remove {
+		unsetFields["interstitial"] = ""
+	} else if patch.Interstitial.Value != nil {
+		setFields["interstitial"] = *patch.Interstitial.Value
+	}
+
+	if patch.CountryTargets.Remove {
+		unsetFields["country_targets"] = ""
+	} else if patch.CountryTargets.Value != nil {
+		setFields["country_targets"] = *patch.CountryTargets.Value
+	}
+
+	if patch.FallbackTarget.Remove {
+		unsetFields["fallback_target"] = ""
+	} else if patch.FallbackTarget.Value != nil {
+		setFields["fallback_target"] = *patch.FallbackTarget.Value
+	}
+
+	if patch.FallbackOn.Remove {
+		unsetFields["fallback_on"] = ""
+	} else if patch.FallbackOn.Value != nil {
+		setFields["fallback_on"] = *patch.FallbackOn.Value
+	}
+
+	if patch.SelfDestructAfter.Remove {
+		unsetFields["self_destruct_after"] = ""
+	} else if patch.SelfDestructAfter.Value != nil {
+		setFields["self_destruct_after"] = *patch.SelfDestructAfter.Value
+	}
+
 	if patch.PasswordHash.Remove {
 		unsetFields["password_hash"] = ""
 	} else if patch.PasswordHash.Value != nil {
 		setFields["password_hash"] = *patch.PasswordHash.Value
 	}
 
-	updateDoc := bson.M{}
-	if len(setFields) > 0 {
-		updateDoc["$set"] = setFields
+	updateDoc := bson.M{
+		"$set": setFields,
+		"$push": bson.M{
+			"revisions": bson.M{
+				"$each":  bson.A{previous},
+				"$slice": -link.MaxRevisions,
+			},
+		},
 	}
 	if len(unsetFields) > 0 {
 		updateDoc["$unset"] = unsetFields
 	}
 
-	if len(updateDoc) == 0 {
-		return nil
+	filter := bson.M{"admin_token": token, "revision": previous.Number}
+	if previous.Number == 0 {
+		// Links created before revisions were introduced have no revision field.
+		filter["revision"] = bson.M{"$in": bson.A{0, nil}}
 	}
 
-	_, err := l.collection.UpdateOne(ctx, bson.M{"admin_token": token}, updateDoc)
-	return err
+	result, err := l.collection.UpdateOne(ctx, filter, updateDoc)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return link.ErrConflict
+	}
+	return nil
+}
+
+// ListRevisionsByToken returns the recorded revisions of a link, newest first.
+// Returns a nil slice if the link is not found.
+func (l *Links) ListRevisionsByToken(ctx context.Context, token string) ([]link.Revision, error) {
+	var result struct {
+		Revisions []link.Revision `bson:"revisions"`
+	}
+	err := l.collection.FindOne(
+		ctx,
+		bson.M{"admin_token": token},
+		options.FindOne().SetProjection(bson.M{"revisions": 1}),
+	).Decode(&result)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]link.Revision, 0, len(result.Revisions))
+	for i := len(result.Revisions) - 1; i >= 0; i-- {
+		rev := result.Revisions[i]
+		rev.HasPassword = rev.PasswordHash != nil
+		revisions = append(revisions, rev)
+	}
+	return revisions, nil
+}
+
+// GetRevisionByToken retrieves a single revision of a link by its number.
+// Returns a nil Revision if the link or revision is not found.
+func (l *Links) GetRevisionByToken(ctx context.Context, token string, number int) (*link.Revision, error) {
+	var result struct {
+		Revisions []link.Revision `bson:"revisions"`
+	}
+	err := l.collection.FindOne(
+		ctx,
+		bson.M{"admin_token": token},
+		options.FindOne().SetProjection(bson.M{
+			"revisions": bson.M{"$elemMatch": bson.M{"number": number}},
+		}),
+	).Decode(&result)
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && len(result.Revisions) == 0) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rev := result.Revisions[0]
+	rev.HasPassword = rev.PasswordHash != nil
+	return &rev, nil
 }
@@ -0,0 +1,258 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lucasmcclean/limitlink/link"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// RawLinks returns a Links wrapper for the store's "links" collection without
+// ensuring its indexes, so maintenance tools can inspect the collection as it
+// is.
+func (store *Store) RawLinks() *Links {
+	return &Links{store.db.Collection(linksCollection)}
+}
+
+// FindByTargetDomain returns the links that may redirect to domain or one of
+// its subdomains. Targets can hide in variants, rules, country targets, and
+// fallbacks, so every link is scanned.
+func (l *Links) FindByTargetDomain(ctx context.Context, domain string) ([]link.Link, error) {
+	cursor, err := l.collection.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"revisions": 0}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var matches []link.Link
+	for cursor.Next(ctx) {
+		var lnk link.Link
+		if err := cursor.Decode(&lnk); err != nil {
+			return nil, fmt.Errorf("decoding link: %w", err)
+		}
+		if lnk.PointsTo(domain) {
+			matches = append(matches, lnk)
+		}
+	}
+	return matches, cursor.Err()
+}
+
+// DisableBySlug marks the link with the given slug as disabled as of at.
+// Links that are already disabled keep their original time.
+func (l *Links) DisableBySlug(ctx context.Context, slug string, at time.Time) (bool, error) {
+	result, err := l.collection.UpdateOne(
+		ctx,
+		bson.M{"slug": slug},
+		bson.A{
+			bson.M{"$set": bson.M{
+				"disabled_at": bson.M{"$ifNull": bson.A{"$disabled_at", at}},
+			}},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount != 0, nil
+}
+
+// EnableBySlug clears the disabled mark of the link with the given slug.
+func (l *Links) EnableBySlug(ctx context.Context, slug string) (bool, error) {
+	result, err := l.collection.UpdateOne(
+		ctx,
+		bson.M{"slug": slug},
+		bson.M{"$unset": bson.M{"disabled_at": ""}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount != 0, nil
+}
+
+// expiredBefore matches links whose admin access expired before the given
+// time. The TTL index removes these eventually, but only once a minute and
+// only while it exists.
+func expiredBefore(before time.Time) bson.M {
+	return bson.M{"admin_expires_at": bson.M{"$lt": before}}
+}
+
+// CountExpired returns the number of links whose admin access expired before
+// the given time.
+func (l *Links) CountExpired(ctx context.Context, before time.Time) (int64, error) {
+	return l.collection.CountDocuments(ctx, expiredBefore(before))
+}
+
+// PurgeExpired deletes the links whose admin access expired before the given
+// time.
+func (l *Links) PurgeExpired(ctx context.Context, before time.Time) (int64, error) {
+	result, err := l.collection.DeleteMany(ctx, expiredBefore(before))
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// MissingIndexes returns the names of required indexes that don't exist on
+// the links collection.
+func (l *Links) MissingIndexes(ctx context.Context) ([]string, error) {
+	specs, err := l.collection.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing indexes: %w", err)
+	}
+
+	present := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		present[spec.Name] = true
+	}
+
+	missing := make([]string, 0, len(requiredIndexes))
+	for _, name := range requiredIndexes {
+		if !present[name] {
+			missing = append(missing, name)
+		}
+	}
+	return missing, nil
+}
+
+// EnsureIndexes creates the TTL and unique indexes if they don't exist.
+func (l *Links) EnsureIndexes(ctx context.Context) error {
+	return errors.Join(l.EnsureTTLIndex(ctx), l.EnsureUniqueIndexes(ctx))
+}
+
+// requiredFields lists the fields every link document must have.
+var requiredFields = []string{"slug", "admin_token", "created_at", "expires_at", "admin_expires_at", "updated_at", "schema_version"}
+
+// CheckIntegrity reports duplicate slugs and admin tokens, documents missing
+// required fields or without any target, and documents from a schema version
+// that can't be read.
+func (l *Links) CheckIntegrity(ctx context.Context) ([]link.IntegrityIssue, error) {
+	var issues []link.IntegrityIssue
+
+	for _, dup := range []struct{ field, kind string }{
+		{"slug", link.IssueDuplicateSlug},
+		{"admin_token", link.IssueDuplicateAdminToken},
+	} {
+		found, err := l.duplicates(ctx, dup.field, dup.kind)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, found...)
+	}
+
+	projection := bson.M{"target": 1, "variants": 1}
+	for _, field := range requiredFields {
+		projection[field] = 1
+	}
+
+	cursor, err := l.collection.Find(ctx, bson.M{}, options.Find().SetProjection(projection))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("decoding link: %w", err)
+		}
+
+		id := idString(doc["_id"])
+		slug, _ := doc["slug"].(string)
+
+		var missing []string
+		for _, field := range requiredFields {
+			if value, ok := doc[field]; !ok || value == nil || value == "" {
+				missing = append(missing, field)
+			}
+		}
+		if target, _ := doc["target"].(string); target == "" && doc["variants"] == nil {
+			missing = append(missing, "target")
+		}
+		if len(missing) != 0 {
+			issues = append(issues, link.IntegrityIssue{
+				Kind:   link.IssueMissingField,
+				ID:     id,
+				Slug:   slug,
+				Detail: "missing " + strings.Join(missing, ", "),
+			})
+		}
+
+		if version, ok := doc["schema_version"]; ok && version != nil && (toInt(version) < link.OldestSchemaVersion || toInt(version) > link.CurrentSchemaVersion) {
+			issues = append(issues, link.IntegrityIssue{
+				Kind:   link.IssueSchemaVersion,
+				ID:     id,
+				Slug:   slug,
+				Detail: fmt.Sprintf("schema version %v, expected %d to %d", version, link.OldestSchemaVersion, link.CurrentSchemaVersion),
+			})
+		}
+	}
+	return issues, cursor.Err()
+}
+
+// duplicates reports every document sharing its value of field with another
+// document. The unique indexes prevent this, but not for data written before
+// they existed or while they were missing.
+func (l *Links) duplicates(ctx context.Context, field, kind string) ([]link.IntegrityIssue, error) {
+	cursor, err := l.collection.Aggregate(ctx, bson.A{
+		bson.M{"$match": bson.M{field: bson.M{"$exists": true}}},
+		bson.M{"$group": bson.M{
+			"_id":   "$" + field,
+			"ids":   bson.M{"$push": "$_id"},
+			"slugs": bson.M{"$push": "$slug"},
+			"count": bson.M{"$sum": 1},
+		}},
+		bson.M{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error finding duplicate %s values: %w", field, err)
+	}
+	defer cursor.Close(ctx)
+
+	var issues []link.IntegrityIssue
+	for cursor.Next(ctx) {
+		var group struct {
+			IDs   []any    `bson:"ids"`
+			Slugs []string `bson:"slugs"`
+		}
+		if err := cursor.Decode(&group); err != nil {
+			return nil, fmt.Errorf("decoding duplicates: %w", err)
+		}
+		for i, id := range group.IDs {
+			issue := link.IntegrityIssue{
+				Kind:   kind,
+				ID:     idString(id),
+				Detail: fmt.Sprintf("%s shared by %d links", field, len(group.IDs)),
+			}
+			if i < len(group.Slugs) {
+				issue.Slug = group.Slugs[i]
+			}
+			issues = append(issues, issue)
+		}
+	}
+	return issues, cursor.Err()
+}
+
+// idString formats a document ID for reports.
+func idString(id any) string {
+	if oid, ok := id.(interface{ Hex() string }); ok {
+		return oid.Hex()
+	}
+	return fmt.Sprint(id)
+}
+
+// toInt converts a numeric BSON value to an int, or returns -1.
+func toInt(value any) int {
+	switch v := value.(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return -1
+}
@@ -51,6 +51,25 @@ func New(ctx context.Context) (*Store, error) {
 	}, nil
 }
 
+// Ping verifies that MongoDB is reachable and that every index required by the
+// links collection is in place. It implements link.Pinger.
+func (store *Store) Ping(ctx context.Context) error {
+	err := store.client.Ping(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error pinging MongoDB: %w", err)
+	}
+
+	missing, err := store.RawLinks().MissingIndexes(ctx)
+	if err != nil {
+		return err
+	}
+	if len(missing) != 0 {
+		return errors.New("missing one or more indexes: " + strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
 // Close disconnects the MongoDB client.
 func (store *Store) Close(ctx context.Context) error {
 	return store.client.Disconnect(ctx)
@@ -0,0 +1,198 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strconv"
+	"strings"
+
+	"rsc.io/qr"
+)
+
+const (
+	// MinSize and MaxSize bound the width and height of an image in pixels.
+	MinSize = 64
+	MaxSize = 2048
+
+	// MaxMargin is the widest quiet zone around the code in modules.
+	MaxMargin = 16
+)
+
+var (
+	ErrInvalidSize   = fmt.Errorf("size must be between %d and %d pixels", MinSize, MaxSize)
+	ErrInvalidMargin = fmt.Errorf("margin must be between 0 and %d modules", MaxMargin)
+	ErrInvalidLevel  = errors.New("error correction level must be one of L, M, Q, H")
+	ErrInvalidColor  = errors.New("colors must be hex RGB values such as 000000 or #fff")
+)
+
+// Options controls how a QR code is rendered.
+type Options struct {
+	Size       int        // Approximate width and height in pixels
+	Level      qr.Level   // Error correction level
+	Margin     int        // Quiet zone around the code in modules
+	Foreground color.RGBA // Color of dark modules
+	Background color.RGBA // Color of light modules and the margin
+}
+
+// DefaultOptions returns black-on-white options with medium error correction
+// and the standard 4-module quiet zone.
+func DefaultOptions() Options {
+	return Options{
+		Size:       256,
+		Level:      qr.M,
+		Margin:     4,
+		Foreground: color.RGBA{0, 0, 0, 0xff},
+		Background: color.RGBA{0xff, 0xff, 0xff, 0xff},
+	}
+}
+
+// Validate checks that the options are in range.
+func (o Options) Validate() error {
+	if o.Size < MinSize || o.Size > MaxSize {
+		return ErrInvalidSize
+	}
+	if o.Margin < 0 || o.Margin > MaxMargin {
+		return ErrInvalidMargin
+	}
+	if o.Level < qr.L || o.Level > qr.H {
+		return ErrInvalidLevel
+	}
+	return nil
+}
+
+// ParseLevel parses an error correction level: L, M, Q, or H.
+func ParseLevel(s string) (qr.Level, error) {
+	switch strings.ToUpper(s) {
+	case "L":
+		return qr.L, nil
+	case "M":
+		return qr.M, nil
+	case "Q":
+		return qr.Q, nil
+	case "H":
+		return qr.H, nil
+	default:
+		return 0, ErrInvalidLevel
+	}
+}
+
+// ParseColor parses an opaque hex RGB color with or without a leading '#',
+// in either the 3- or 6-digit form.
+func ParseColor(s string) (color.RGBA, error) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) != 6 {
+		return color.RGBA{}, ErrInvalidColor
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.RGBA{}, ErrInvalidColor
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xff}, nil
+}
+
+// code is an encoded QR code with the layout derived from the options.
+type code struct {
+	*qr.Code
+	margin int // Quiet zone in modules
+	scale  int // Pixels per module
+}
+
+// encode encodes text and fits it into the requested size. The image is
+// never smaller than one pixel per module, so it may exceed Size for long
+// text at small sizes.
+func encode(text string, opts Options) (*code, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	c, err := qr.Encode(text, opts.Level)
+	if err != nil {
+		return nil, err
+	}
+	modules := c.Size + 2*opts.Margin
+	return &code{Code: c, margin: opts.Margin, scale: max(opts.Size/modules, 1)}, nil
+}
+
+// dark reports whether the module at (x, y), including the margin, is dark.
+func (c *code) dark(x, y int) bool {
+	return c.Black(x-c.margin, y-c.margin)
+}
+
+// modules returns the number of modules on a side, including the margin.
+func (c *code) modules() int {
+	return c.Size + 2*c.margin
+}
+
+// PNG renders text as a QR code in the PNG format.
+func PNG(text string, opts Options) ([]byte, error) {
+	c, err := encode(text, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	side := c.modules() * c.scale
+	img := image.NewPaletted(image.Rect(0, 0, side, side), color.Palette{opts.Background, opts.Foreground})
+	for y := 0; y < c.modules(); y++ {
+		for x := 0; x < c.modules(); x++ {
+			if !c.dark(x, y) {
+				continue
+			}
+			for py := y * c.scale; py < (y+1)*c.scale; py++ {
+				row := img.Pix[py*img.Stride:]
+				for px := x * c.scale; px < (x+1)*c.scale; px++ {
+					row[px] = 1
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG renders text as a QR code in the SVG format. Modules are drawn as a
+// single path in module units and scaled to the requested size.
+func SVG(text string, opts Options) ([]byte, error) {
+	c, err := encode(text, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	n := c.modules()
+	side := n * c.scale
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, side, side, n, n)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="%s"/>`, n, n, hex(opts.Background))
+	fmt.Fprintf(&b, `<path fill="%s" d="`, hex(opts.Foreground))
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if !c.dark(x, y) {
+				continue
+			}
+			// Merge horizontal runs of dark modules into one rectangle.
+			run := 1
+			for x+run < n && c.dark(x+run, y) {
+				run++
+			}
+			fmt.Fprintf(&b, "M%d %dh%dv1h-%dz", x, y, run, run)
+			x += run - 1
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	return b.Bytes(), nil
+}
+
+// hex formats an opaque color as #rrggbb.
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
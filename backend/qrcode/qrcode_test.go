@@ -0,0 +1,128 @@
+package qrcode
+
+import (
+	"bytes"
+	"flag"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rsc.io/qr"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+var goldenCases = []struct {
+	name string
+	text string
+	opts func(*Options)
+}{
+	{"default", "https://example.com/abcdef", func(*Options) {}},
+	{"high_colors", "https://example.com/abcdef", func(o *Options) {
+		o.Level = qr.H
+		o.Foreground = color.RGBA{0x1a, 0x3c, 0x8f, 0xff}
+		o.Background = color.RGBA{0xff, 0xf8, 0xe7, 0xff}
+	}},
+	{"small_no_margin", "https://example.com/x", func(o *Options) {
+		o.Size = MinSize
+		o.Margin = 0
+		o.Level = qr.L
+	}},
+	{"long_text", "https://example.com/0123456789abcdefghijklmnopqrstuvwxyz?utm_source=print&utm_medium=poster", func(o *Options) {
+		o.Size = MinSize
+	}},
+}
+
+// golden compares got with the named file in testdata, or rewrites the file
+// when -update is set.
+func golden(t *testing.T, name string, got []byte, equal func(want, got []byte) bool) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if !equal(want, got) {
+		t.Errorf("output differs from %s (run go test -update if the change is intended)", path)
+	}
+}
+
+func TestPNGGolden(t *testing.T) {
+	for _, tc := range goldenCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			tc.opts(&opts)
+			got, err := PNG(tc.text, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden(t, tc.name+".png", got, samePixels)
+		})
+	}
+}
+
+func TestSVGGolden(t *testing.T) {
+	for _, tc := range goldenCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			tc.opts(&opts)
+			got, err := SVG(tc.text, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden(t, tc.name+".svg", got, bytes.Equal)
+		})
+	}
+}
+
+// samePixels reports whether two PNG images decode to the same pixels, so the
+// goldens don't depend on the encoder's compression.
+func samePixels(want, got []byte) bool {
+	a, err := png.Decode(bytes.NewReader(want))
+	if err != nil {
+		return false
+	}
+	b, err := png.Decode(bytes.NewReader(got))
+	if err != nil || a.Bounds() != b.Bounds() {
+		return false
+	}
+	for y := a.Bounds().Min.Y; y < a.Bounds().Max.Y; y++ {
+		for x := a.Bounds().Min.X; x < a.Bounds().Max.X; x++ {
+			if !sameColor(a.At(x, y), b.At(x, y)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func sameColor(a, b color.Color) bool {
+	r1, g1, b1, a1 := a.RGBA()
+	r2, g2, b2, a2 := b.RGBA()
+	return r1 == r2 && g1 == g2 && b1 == b2 && a1 == a2
+}
+
+func TestPNGSize(t *testing.T) {
+	opts := DefaultOptions()
+	data, err := PNG("https://example.com/abcdef", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Width != cfg.Height || cfg.Width > opts.Size || cfg.Width < opts.Size/2 {
+		t.Errorf("image is %dx%d, want a square of about %d pixels", cfg.Width, cfg.Height, opts.Size)
+	}
+}
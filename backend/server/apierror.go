@@ -0,0 +1,52 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/lucasmcclean/limitlink/link"
+)
+
+// apiError is the body of an error response to a client that accepts JSON.
+type apiError struct {
+	Error string `json:"error"` // Human-readable message
+	Code  string `json:"code"`  // Stable machine-readable code
+}
+
+// writeError replies with message and status, like http.Error. Clients that
+// accept JSON get the message with a code derived from the status, such as
+// "not_found".
+func writeError(w http.ResponseWriter, r *http.Request, message string, status int) {
+	writeErrorCode(w, r, message, statusCode(status), status)
+}
+
+// writeLinkError replies with err and status. Clients that accept JSON get
+// the code of the link package error err wraps (see link.ErrorCode), or one
+// derived from the status if it wraps none.
+func writeLinkError(w http.ResponseWriter, r *http.Request, err error, status int) {
+	code := link.ErrorCode(err)
+	if code == "" {
+		code = statusCode(status)
+	}
+	writeErrorCode(w, r, err.Error(), code, status)
+}
+
+// writeErrorCode replies with message and status, as JSON including code if
+// the client accepts it and as plain text otherwise.
+func writeErrorCode(w http.ResponseWriter, r *http.Request, message, code string, status int) {
+	if !strings.Contains(r.Header.Get("Accept"), "application/json") {
+		http.Error(w, message, status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(apiError{Error: message, Code: code})
+}
+
+// statusCode returns the error code for an HTTP status, such as "not_found".
+func statusCode(status int) string {
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
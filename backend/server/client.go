@@ -0,0 +1,90 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// clientIP returns the IP address of the client that sent r, or the zero Addr
+// if it can't be determined.
+//
+// X-Forwarded-For is only consulted when the direct peer is a trusted proxy.
+// The header is then walked from the nearest hop outwards, and the first
+// address that is not a trusted proxy is the client, so entries added by the
+// client itself are never believed.
+func clientIP(r *http.Request, trusted []netip.Prefix) netip.Addr {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+	ip = ip.Unmap()
+
+	if !isTrusted(ip, trusted) {
+		return ip
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		hopIP, err := netip.ParseAddr(hop)
+		if err != nil {
+			return netip.Addr{}
+		}
+		ip = hopIP.Unmap()
+		if !isTrusted(ip, trusted) {
+			return ip
+		}
+	}
+	return ip
+}
+
+// isTrusted reports whether ip belongs to one of the trusted networks.
+func isTrusted(ip netip.Addr, trusted []netip.Prefix) bool {
+	for _, prefix := range trusted {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientCountry returns the country of ip, or "" if it is unknown or no
+// country lookup is configured.
+func clientCountry(ip netip.Addr, countries CountryLookup) string {
+	if countries == nil || !ip.IsValid() {
+		return ""
+	}
+	return countries.Country(ip)
+}
+
+// parsePrefixes parses a comma-separated list of networks in CIDR notation or
+// bare IP addresses.
+func parsePrefixes(list string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, s := range strings.Split(list, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if prefix, err := netip.ParsePrefix(s); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		ip, err := netip.ParseAddr(s)
+		if err != nil {
+			return nil, err
+		}
+		ip = ip.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(ip, ip.BitLen()))
+	}
+	return prefixes, nil
+}
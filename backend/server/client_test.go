@@ -0,0 +1,40 @@
+package server
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/lucasmcclean/limitlink/geoip"
+)
+
+func TestClientCountry(t *testing.T) {
+	db, err := geoip.Open("../geoip/testdata/country.mmdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close(context.Background()) })
+
+	tests := []struct {
+		name      string
+		ip        netip.Addr
+		countries CountryLookup
+		want      string
+	}{
+		{"hit", netip.MustParseAddr("81.2.69.160"), db, "GB"},
+		{"ipv6 hit", netip.MustParseAddr("2001:db8:1::1"), db, "JP"},
+		{"registered country", netip.MustParseAddr("89.160.20.112"), db, "SE"},
+		{"miss", netip.MustParseAddr("1.1.1.1"), db, ""},
+		{"ipv4-mapped ipv6", netip.MustParseAddr("::ffff:81.2.69.160"), db, "GB"},
+		{"unknown ip", netip.Addr{}, db, ""},
+		{"nil database", netip.MustParseAddr("81.2.69.160"), nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clientCountry(tt.ip, tt.countries); got != tt.want {
+				t.Errorf("clientCountry(%s) = %q, want %q", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
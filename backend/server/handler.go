@@ -4,26 +4,52 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/lucasmcclean/limitlink/link"
+	"github.com/lucasmcclean/limitlink/metrics"
 )
 
 // RedirectHandler redirects GET requests to their matching target.
+// HEAD requests go through the same checks and get the same status and
+// Location, but no body, and don't count a hit. Requests for /slug+ or with
+// ?preview=1 render a preview of the link instead, and /slug/qr returns its
+// QR code, so a path of exactly "qr" is never forwarded to the target. Links
+// with an interstitial page, or targets outside the server's allowed domains,
+// show a warning page first and only count the hit once the visitor continues.
+// The target of the first routing rule matching the request is used before
+// any country target, variants, or the default target. Visitors from
+// networks, countries, or referring sites the link is restricted from are
+// refused without counting a hit. The path after the slug and the query are
+// forwarded to the target as configured by the link's passthrough options,
+// after filling in template variables and adding campaign parameters.
 // It will first verify that the link is available and fail if it can't
-// increment the hit count.
-func RedirectHandler(links link.Repository) http.HandlerFunc {
+// increment the hit count. Unavailable links are redirected to their fallback
+// target instead when one is configured for the reason. Redirects use the
+// link's status code or the server default and are never cacheable.
+func RedirectHandler(links link.Repository, cfg Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
 		}
+		head := r.Method == http.MethodHead
+
+		// Links are limited, so their redirects must never be cached, and short
+		// links shouldn't be indexed or leak to the target as the referrer.
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		w.Header().Set("X-Robots-Tag", "noindex")
 
 		path := strings.Trim(r.URL.Path, "/")
 		slug := strings.Split(path, "/")[0]
+		preview := isPreview(r, slug)
+		slug = strings.TrimSuffix(slug, previewSuffix)
 
 		if slug == "" {
 			http.Error(w, "Missing slug", http.StatusBadRequest)
@@ -37,45 +63,206 @@ func RedirectHandler(links link.Repository) http.HandlerFunc {
 
 		lnk, err := links.GetBySlug(r.Context(), slug)
 		if err != nil || lnk == nil {
+			metrics.ObserveRedirect(metrics.RedirectNotFound)
 			http.Error(w, "Link not found", http.StatusNotFound)
 			return
 		}
 
-		if !lnk.IsAvailable(time.Now()) {
-			http.Error(w, "Link not found", http.StatusNotFound)
+		if suffix := pathSuffix(r.URL.Path); len(suffix) == 1 && suffix[0] == "qr" {
+			serveQR(w, r, lnk.Slug)
+			return
+		}
+
+		now := time.Now()
+		if preview {
+			renderPreview(w, r, lnk, cfg, now)
+			return
+		}
+
+		info := requestInfo(r, cfg)
+		if !lnk.AllowsIP(info.IP) {
+			slog.WarnContext(r.Context(), "redirect denied by network restriction", slog.String("slug", slug), slog.String("client_ip", ipString(info.IP)))
+			metrics.ObserveRedirect(metrics.RedirectIPDenied)
+			http.Error(w, "Link is not available from your network", http.StatusForbidden)
+			return
+		}
+
+		if !lnk.AllowsCountry(info.Country) {
+			metrics.ObserveRedirect(metrics.RedirectGeoBlocked)
+			http.Error(w, "Link is not available in your country", http.StatusForbidden)
+			return
+		}
+
+		if !lnk.AllowsReferrer(info.ReferrerHost, cfg.NoReferrer) {
+			metrics.ObserveRedirect(metrics.RedirectReferrer)
+			http.Error(w, "Link can't be followed from this site", http.StatusForbidden)
 			return
 		}
 
 		if lnk.PasswordHash != nil {
 			password := r.Header.Get("X-Link-Password")
 			if password == "" {
+				metrics.ObserveRedirect(metrics.RedirectPassword)
 				http.Error(w, "Password required", http.StatusUnauthorized)
 				return
 			}
-			valid, err := lnk.IsCorrectPassword(password)
+			start := time.Now()
+			valid, err := lnk.IsCorrectPassword(r.Context(), password)
+			metrics.ObservePasswordCheck(time.Since(start))
 			if err != nil {
+				slog.ErrorContext(r.Context(), "error validating password", slog.String("slug", slug), slog.Any("error", err))
+				metrics.ObserveRedirect(metrics.RedirectError)
 				http.Error(w, "Error validating password", http.StatusInternalServerError)
 				return
 			}
 			if !valid {
+				metrics.ObserveRedirect(metrics.RedirectPassword)
 				http.Error(w, "Invalid password", http.StatusUnauthorized)
 				return
 			}
 		}
 
-		err = links.IncBySlug(r.Context(), slug)
+		status := lnk.Status(now)
+
+		if target, ok := lnk.FallbackFor(status); ok {
+			if head {
+				metrics.ObserveRedirect(metrics.RedirectHead)
+			} else if err = links.IncFallbackBySlug(r.Context(), slug); err != nil {
+				slog.ErrorContext(r.Context(), "error incrementing fallback hit count", slog.String("slug", slug), slog.Any("error", err))
+				metrics.ObserveRedirect(metrics.RedirectError)
+				http.Error(w, "Error retrieving link", http.StatusInternalServerError)
+				return
+			} else {
+				metrics.ObserveRedirect(metrics.RedirectFallback)
+			}
+			http.Redirect(w, r, link.RenderTarget(target, info, now), lnk.RedirectCodeOr(cfg.RedirectCode))
+			return
+		}
+
+		switch status {
+		case link.StatusAvailable:
+		case link.StatusNotYetValid:
+			metrics.ObserveRedirect(metrics.RedirectNotYetValid)
+			notYetOpen(w, lnk.NextOpening(now), now)
+			return
+		case link.StatusClosed:
+			metrics.ObserveRedirect(metrics.RedirectClosed)
+			notYetOpen(w, lnk.NextOpening(now), now)
+			return
+		case link.StatusExpired:
+			metrics.ObserveRedirect(metrics.RedirectExpired)
+			http.Error(w, "Link not found", http.StatusNotFound)
+			return
+		case link.StatusExhausted:
+			metrics.ObserveRedirect(metrics.RedirectExhausted)
+			http.Error(w, "Link not found", http.StatusNotFound)
+			return
+		case link.StatusDisabled:
+			metrics.ObserveRedirect(metrics.RedirectDisabled)
+			http.Error(w, "This link has been disabled", http.StatusGone)
+			return
+		}
+
+		continuedVariant, isContinued := cfg.Interstitial.continued(r, slug, now)
+
+		target := lnk.Target
+		var variant *link.Variant
+		if _, rule := lnk.MatchRule(info); rule != nil {
+			target = rule.Target
+		} else if countryTarget, ok := lnk.CountryTarget(info.Country); ok {
+			target = countryTarget
+		} else if variant = chooseVariant(w, r, lnk, continuedVariant); variant != nil {
+			target = variant.Target
+		}
+
+		target = link.RenderTarget(target, info, now)
+		target, err = lnk.Tag(target, cfg.Campaign)
+		if err == nil {
+			target, err = lnk.Forward(target, info.Path, info.Query)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if page := interstitialFor(lnk, target, cfg.Interstitial); page != nil && !isContinued {
+			metrics.ObserveRedirect(metrics.RedirectInterstitial)
+			renderInterstitial(w, r, slug, target, variant, page, cfg.Interstitial, now)
+			return
+		}
+
+		switch {
+		case head:
+			metrics.ObserveRedirect(metrics.RedirectHead)
+		case variant != nil:
+			err = links.IncVariantBySlug(r.Context(), slug, variant.Name)
+		default:
+			err = links.IncBySlug(r.Context(), slug)
+		}
 		if err != nil {
+			slog.ErrorContext(r.Context(), "error incrementing hit count", slog.String("slug", slug), slog.Any("error", err))
+			metrics.ObserveRedirect(metrics.RedirectError)
 			http.Error(w, "Error retrieving link", http.StatusInternalServerError)
 			return
 		}
 
-		http.Redirect(w, r, lnk.Target, http.StatusFound)
+		if !head {
+			metrics.ObserveRedirect(metrics.RedirectOK)
+		}
+		http.Redirect(w, r, target, lnk.RedirectCodeOr(cfg.RedirectCode))
+	}
+}
+
+// pathSuffix returns the path segments following the slug, or nil if there are
+// none. A trailing slash yields a final empty segment.
+func pathSuffix(path string) []string {
+	_, rest, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if rest == "" {
+		return nil
 	}
+	return strings.Split(rest, "/")
 }
 
-// LinkHandler routes POST, PATCH, and GET requests to the appropriate handlers.
-func LinkHandler(links link.Repository) http.HandlerFunc {
+// notYetOpen responds to a request for a link that is not open yet, including
+// when it will next open. Links that will never open again are reported as
+// not found.
+func notYetOpen(w http.ResponseWriter, next *time.Time, now time.Time) {
+	if next == nil {
+		http.Error(w, "Link not found", http.StatusNotFound)
+		return
+	}
+
+	retryAfter := int(next.Sub(now).Round(time.Second) / time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+	w.Header().Set("X-Link-Opens-At", next.UTC().Format(time.RFC3339))
+	http.Error(w, "Link is not open yet. It opens at "+next.UTC().Format(time.RFC3339), http.StatusForbidden)
+}
+
+// LinkHandler routes POST, PATCH, GET, and DELETE requests to the appropriate
+// handlers.
+// Requests for /links/admin-token/dry-run are routed to the rule dry run,
+// /links/admin-token/qr to the QR code of the short URL,
+// /links/admin-token/stats to the usage statistics, and
+// those for /links/admin-token/revisions and below to the revision handlers.
+func LinkHandler(links link.Repository, cfg Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if sub := adminSubpath(r); len(sub) != 0 {
+			if len(sub) == 1 && sub[0] == "dry-run" {
+				dryRun(w, r, links, cfg)
+				return
+			}
+			if len(sub) == 1 && sub[0] == "qr" {
+				adminQR(w, r, links)
+				return
+			}
+			if len(sub) == 1 && sub[0] == "stats" {
+				getStats(w, r, links)
+				return
+			}
+			revisionsHandler(w, r, links, sub)
+			return
+		}
+
 		switch r.Method {
 		case http.MethodPost:
 			postLink(w, r, links)
@@ -83,30 +270,42 @@ func LinkHandler(links link.Repository) http.HandlerFunc {
 			getLink(w, r, links)
 		case http.MethodPatch:
 			patchLink(w, r, links)
+		case http.MethodDelete:
+			deleteLink(w, r, links)
 		default:
 			w.WriteHeader(http.StatusMethodNotAllowed)
 		}
 	}
 }
 
+// publicBaseURL is the base of every short URL.
+const publicBaseURL = "https://limitl.ink/"
+
+// shortURL returns the public short URL of slug.
+func shortURL(slug string) string {
+	return publicBaseURL + slug
+}
+
 // postLink handles HTTP POST requests to create a new shortened link.
 // It expects a JSON body containing all required fields and possibly optional fields.
 func postLink(w http.ResponseWriter, r *http.Request, links link.Repository) {
 	var validated *link.Validated
 	var err error
 
-	validated, err = link.FromJSON(r.Body, time.Now())
+	validated, err = link.FromJSON(r.Context(), r.Body, time.Now())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeLinkError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	if err := links.Create(r.Context(), validated); err != nil {
-		log.Printf("error storing link: %v", err)
-		http.Error(w, "Something went wrong while saving your link. Please try again later.", http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "error storing link", slog.Any("error", err))
+		writeError(w, r, "Something went wrong while saving your link. Please try again later.", http.StatusInternalServerError)
 		return
 	}
 
+	metrics.IncLinksCreated()
+
 	lnk := validated.Link()
 
 	resp := struct {
@@ -117,8 +316,8 @@ func postLink(w http.ResponseWriter, r *http.Request, links link.Repository) {
 	}{
 		Slug:        lnk.Slug,
 		AdminToken:  lnk.AdminToken,
-		RedirectURL: "https://limitl.ink/" + lnk.Slug,
-		AdminURL:    "https://limitl.ink/admin/" + lnk.AdminToken,
+		RedirectURL: shortURL(lnk.Slug),
+		AdminURL:    publicBaseURL + "admin/" + lnk.AdminToken,
 	}
 
 	w.Header().Set("Location", "/admin/"+lnk.AdminToken)
@@ -126,8 +325,8 @@ func postLink(w http.ResponseWriter, r *http.Request, links link.Repository) {
 	w.WriteHeader(http.StatusCreated)
 
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		log.Printf("error encoding created link: %v", err)
-		http.Error(w, "Error encoding created link", http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "error encoding created link", slog.Any("error", err))
+		writeError(w, r, "Error encoding created link", http.StatusInternalServerError)
 	}
 }
 
@@ -136,65 +335,151 @@ func postLink(w http.ResponseWriter, r *http.Request, links link.Repository) {
 func patchLink(w http.ResponseWriter, r *http.Request, links link.Repository) {
 	adminToken, err := extractAdminToken(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+		writeLinkError(w, r, err, http.StatusUnauthorized)
 		return
 	}
 
 	original, err := links.GetByToken(r.Context(), adminToken)
-	if err != nil {
-		http.Error(w, "Link not found or invalid admin token", http.StatusNotFound)
+	if err != nil || original == nil {
+		writeError(w, r, "Link not found or invalid admin token", http.StatusNotFound)
 		return
 	}
 
 	data, err := io.ReadAll(r.Body)
 	if err != nil {
-		http.Error(w, "Could not read request body", http.StatusBadRequest)
+		writeError(w, r, "Could not read request body", http.StatusBadRequest)
 		return
 	}
 
-	patch, err := link.PatchFromJSON(data, original)
+	patch, err := link.PatchFromJSON(r.Context(), data, original)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeLinkError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	if err := links.PatchByToken(r.Context(), adminToken, patch); err != nil {
-		http.Error(w, "Error updating link", http.StatusInternalServerError)
+		if errors.Is(err, link.ErrConflict) {
+			writeLinkError(w, r, err, http.StatusConflict)
+			return
+		}
+		slog.ErrorContext(r.Context(), "error updating link", slog.Any("error", err))
+		writeError(w, r, "Error updating link", http.StatusInternalServerError)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// deleteLink permanently deletes a link.
+// It expects the admin token in the URL path to authorize the request.
+func deleteLink(w http.ResponseWriter, r *http.Request, links link.Repository) {
+	adminToken, err := extractAdminToken(r)
+	if err != nil {
+		writeLinkError(w, r, err, http.StatusUnauthorized)
+		return
+	}
+
+	lnk, err := links.GetByToken(r.Context(), adminToken)
+	if err != nil || lnk == nil {
+		writeError(w, r, "Link not found or invalid admin token", http.StatusNotFound)
+		return
+	}
+
+	if err := links.DeleteByToken(r.Context(), adminToken); err != nil {
+		slog.ErrorContext(r.Context(), "error deleting link", slog.Any("error", err))
+		writeError(w, r, "Error deleting link", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getStats returns the usage statistics of a link.
+// It expects the admin token in the URL path to authorize the request.
+func getStats(w http.ResponseWriter, r *http.Request, links link.Repository) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	adminToken, err := extractAdminToken(r)
+	if err != nil {
+		writeLinkError(w, r, err, http.StatusUnauthorized)
+		return
+	}
+
+	lnk, err := links.GetByToken(r.Context(), adminToken)
+	if err != nil || lnk == nil {
+		writeError(w, r, "Link not found or invalid admin token", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(lnk.Stats(time.Now())); err != nil {
+		slog.ErrorContext(r.Context(), "error serializing stats", slog.Any("error", err))
+		writeError(w, r, "Error serializing stats", http.StatusInternalServerError)
+	}
+}
+
+// adminQR returns the QR code of a link's short URL.
+// It expects the admin token in the URL path to authorize the request.
+func adminQR(w http.ResponseWriter, r *http.Request, links link.Repository) {
+	adminToken, err := extractAdminToken(r)
+	if err != nil {
+		writeLinkError(w, r, err, http.StatusUnauthorized)
+		return
+	}
+
+	lnk, err := links.GetByToken(r.Context(), adminToken)
+	if err != nil || lnk == nil {
+		writeError(w, r, "Link not found or invalid admin token", http.StatusNotFound)
+		return
+	}
+
+	serveQR(w, r, lnk.Slug)
+}
+
 // getLink returns the current state of a link.
 // It expects a Bearer token to authorize the request.
 func getLink(w http.ResponseWriter, r *http.Request, links link.Repository) {
 	adminToken, err := extractAdminToken(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
+		writeLinkError(w, r, err, http.StatusUnauthorized)
 		return
 	}
 
 	link, err := links.GetByToken(r.Context(), adminToken)
 	if err != nil || link == nil {
-		http.Error(w, "Link not found or invalid admin token", http.StatusNotFound)
+		writeError(w, r, "Link not found or invalid admin token", http.StatusNotFound)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(link.ToPublic()); err != nil {
-		http.Error(w, "Error serializing link", http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "error serializing link", slog.Any("error", err))
+		writeError(w, r, "Error serializing link", http.StatusInternalServerError)
 	}
 }
 
 // extractBearerToken parses the URL and extracts the admin token.
-// Expects the request to be of the form /links/admin-token.
+// Expects the request to be of the form /links/admin-token[/...].
 func extractAdminToken(r *http.Request) (string, error) {
-	path := strings.TrimPrefix(r.URL.Path, "/links/")
-	token := strings.Trim(path, "/")
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/links/"), "/")
+	token, _, _ := strings.Cut(path, "/")
 
 	if token == "" {
 		return "", errors.New("missing admin token in URL path")
 	}
 	return token, nil
 }
+
+// adminSubpath returns the path segments following the admin token, if any.
+// For /links/admin-token/revisions/3/rollback it returns [revisions 3 rollback].
+func adminSubpath(r *http.Request) []string {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/links/"), "/")
+	_, rest, found := strings.Cut(path, "/")
+	if !found || rest == "" {
+		return nil
+	}
+	return strings.Split(rest, "/")
+}
@@ -0,0 +1,173 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lucasmcclean/limitlink/geoip"
+	"github.com/lucasmcclean/limitlink/link"
+	"github.com/lucasmcclean/limitlink/link/linktest"
+)
+
+func TestHeadMatchesGet(t *testing.T) {
+	now := time.Now()
+	maxHits := 1
+	fallback := "https://example.com/fallback"
+
+	tests := []struct {
+		name string
+		lnk  link.Link
+	}{
+		{"available", link.Link{Target: "https://example.com/target"}},
+		{"fallback", link.Link{Target: "https://example.com/target", MaxHits: &maxHits, HitCount: 1, FallbackTarget: &fallback}},
+		{"password", link.Link{Target: "https://example.com/target", PasswordHash: new(string)}},
+		{"interstitial", link.Link{Target: "https://example.com/target", Interstitial: &link.Interstitial{Countdown: 5}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := linktest.NewRepository()
+			lnk := tt.lnk
+			lnk.Slug = "abcdef"
+			lnk.CreatedAt = now
+			lnk.ExpiresAt = now.Add(time.Hour)
+			repo.Add(&lnk)
+			handler := RedirectHandler(repo, Config{RedirectCode: http.StatusFound})
+
+			head := httptest.NewRecorder()
+			handler.ServeHTTP(head, httptest.NewRequest(http.MethodHead, "/abcdef", nil))
+			after, _ := repo.GetBySlug(context.Background(), "abcdef")
+			if after.HitCount != lnk.HitCount || after.FallbackHitCount != lnk.FallbackHitCount {
+				t.Error("HEAD counted a hit")
+			}
+
+			get := httptest.NewRecorder()
+			handler.ServeHTTP(get, httptest.NewRequest(http.MethodGet, "/abcdef", nil))
+			if head.Code != get.Code {
+				t.Errorf("HEAD status = %d, GET status = %d", head.Code, get.Code)
+			}
+			if h, g := head.Header().Get("Location"), get.Header().Get("Location"); h != g {
+				t.Errorf("HEAD Location = %q, GET Location = %q", h, g)
+			}
+		})
+	}
+}
+
+func TestFallbackChecksAccess(t *testing.T) {
+	db, err := geoip.Open("../geoip/testdata/country.mmdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close(context.Background()) })
+
+	now := time.Now()
+	fallback := "https://example.com/fallback"
+
+	tests := []struct {
+		name string
+		lnk  link.Link
+		want int
+	}{
+		{"unrestricted", link.Link{}, http.StatusFound},
+		{"allowed country", link.Link{AllowedCountries: []string{"GB"}}, http.StatusFound},
+		{"denied country", link.Link{AllowedCountries: []string{"SE"}}, http.StatusForbidden},
+		{"allowed network", link.Link{AllowedCIDRs: []string{"81.2.69.0/24"}}, http.StatusFound},
+		{"denied network", link.Link{AllowedCIDRs: []string{"10.0.0.0/8"}}, http.StatusForbidden},
+		{"allowed referrer", link.Link{AllowedReferrers: []string{"example.org"}}, http.StatusFound},
+		{"denied referrer", link.Link{AllowedReferrers: []string{"example.net"}}, http.StatusForbidden},
+		{"password", link.Link{PasswordHash: new(string)}, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := linktest.NewRepository()
+			lnk := tt.lnk
+			lnk.Slug = "abcdef"
+			lnk.Target = "https://example.com/target"
+			lnk.FallbackTarget = &fallback
+			lnk.CreatedAt = now.Add(-2 * time.Hour)
+			lnk.ExpiresAt = now.Add(-time.Hour)
+			repo.Add(&lnk)
+
+			// 81.2.69.160 is in GB in the test database.
+			req := httptest.NewRequest(http.MethodGet, "/abcdef", nil)
+			req.RemoteAddr = "81.2.69.160:1234"
+			req.Header.Set("Referer", "https://example.org/post")
+			rec := httptest.NewRecorder()
+			RedirectHandler(repo, Config{RedirectCode: http.StatusFound, Countries: db}).ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			after, _ := repo.GetBySlug(context.Background(), "abcdef")
+			if counted := after.FallbackHitCount != 0; counted != (tt.want == http.StatusFound) {
+				t.Errorf("fallback hits = %d after status %d", after.FallbackHitCount, rec.Code)
+			}
+		})
+	}
+}
+
+func TestPreviewHidesRestrictedDestinations(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		lnk    link.Link
+		hidden bool
+	}{
+		{"unrestricted", link.Link{}, false},
+		{"password", link.Link{PasswordHash: new(string)}, true},
+		{"network", link.Link{AllowedCIDRs: []string{"10.0.0.0/8"}}, true},
+		{"country", link.Link{AllowedCountries: []string{"NZ"}}, true},
+		{"referrer", link.Link{AllowedReferrers: []string{"example.org"}, NoReferrer: link.ReferrerDeny}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := linktest.NewRepository()
+			lnk := tt.lnk
+			lnk.Slug = "abcdef"
+			lnk.Target = "https://destination.example.com"
+			lnk.CreatedAt = now
+			lnk.ExpiresAt = now.Add(time.Hour)
+			repo.Add(&lnk)
+
+			rec := httptest.NewRecorder()
+			RedirectHandler(repo, Config{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/abcdef+", nil))
+			if shown := strings.Contains(rec.Body.String(), "destination.example.com"); shown == tt.hidden {
+				t.Errorf("destination shown = %v, want %v", shown, !tt.hidden)
+			}
+		})
+	}
+}
+
+func TestQRIsReservedAndUncounted(t *testing.T) {
+	repo := linktest.NewRepository()
+	now := time.Now()
+	repo.Add(&link.Link{
+		Slug:        "abcdef",
+		Target:      "https://example.com/docs",
+		Passthrough: &link.Passthrough{Path: true},
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(time.Hour),
+	})
+	handler := RedirectHandler(repo, Config{RedirectCode: http.StatusFound})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/abcdef/qr", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" {
+		t.Errorf("/abcdef/qr = %d %s, want a PNG", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if lnk, _ := repo.GetBySlug(context.Background(), "abcdef"); lnk.HitCount != 0 {
+		t.Errorf("QR fetch counted %d hits", lnk.HitCount)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/abcdef/qr/", nil))
+	if got, want := rec.Header().Get("Location"), "https://example.com/docs/qr/"; got != want {
+		t.Errorf("/abcdef/qr/ Location = %q, want %q", got, want)
+	}
+}
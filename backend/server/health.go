@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/lucasmcclean/limitlink/link"
+)
+
+// readyTimeout bounds how long a readiness check may wait on the store.
+const readyTimeout = 2 * time.Second
+
+var errShuttingDown = errors.New("server is shutting down")
+
+// Health tracks whether the server should receive traffic.
+// It starts ready and is flipped to not-ready once shutdown begins.
+type Health struct {
+	pinger   link.Pinger
+	shutting atomic.Bool
+}
+
+// NewHealth returns a Health whose readiness depends on pinger.
+// A nil pinger means only the shutdown state is considered.
+func NewHealth(pinger link.Pinger) *Health {
+	return &Health{pinger: pinger}
+}
+
+// SetNotReady marks the server as shutting down so readiness checks fail and
+// load balancers stop routing new requests to it.
+func (h *Health) SetNotReady() {
+	h.shutting.Store(true)
+}
+
+// Ready returns nil if the server is accepting traffic and its store is
+// reachable, or an error describing why it is not ready.
+func (h *Health) Ready(ctx context.Context) error {
+	if h.shutting.Load() {
+		return errShuttingDown
+	}
+	if h.pinger == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readyTimeout)
+	defer cancel()
+
+	return h.pinger.Ping(ctx)
+}
+
+// LivenessHandler reports that the process is up and able to serve requests.
+func LivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok\n"))
+	}
+}
+
+// ReadinessHandler reports whether the server should receive traffic.
+// It responds 503 while shutting down or if the store is not ready.
+func ReadinessHandler(health *Health) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+		if err := health.Ready(r.Context()); err != nil {
+			slog.WarnContext(r.Context(), "readiness check failed", slog.Any("error", err))
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("not ready\n"))
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ready\n"))
+	}
+}
@@ -0,0 +1,233 @@
+package server
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lucasmcclean/limitlink/metrics"
+)
+
+const (
+	// Error codes of idempotency failures, see writeErrorCode.
+	codeIdempotencyKeyReused = "idempotency_key_reused"
+	codeIdempotencyKeyInUse  = "idempotency_key_in_use"
+
+	// idempotencyKeyHeader carries the client-chosen key of a retryable request.
+	idempotencyKeyHeader = "Idempotency-Key"
+
+	// maxIdempotencyKeyLen is the longest accepted idempotency key.
+	maxIdempotencyKeyLen = 255
+
+	// idempotencyTTL is how long a response is replayed for the same key,
+	// counted from when the response was recorded.
+	idempotencyTTL = 24 * time.Hour
+
+	// maxIdempotencyEntries bounds the number of remembered responses. Once it
+	// is reached, the oldest key is evicted for each new one.
+	maxIdempotencyEntries = 10_000
+)
+
+// idempotentResponse is a remembered response, or a request still in progress
+// if done is false.
+type idempotentResponse struct {
+	key      string
+	elem     *list.Element // Position in the cache's pending or done list
+	bodyHash [sha256.Size]byte
+	done     bool
+	status   int
+	header   http.Header
+	body     []byte
+	expires  time.Time
+}
+
+// idempotencyCache remembers the responses of requests by idempotency key so
+// retries of a request that already succeeded don't repeat its effects.
+// Responses are kept in memory, so keys are only honored by the instance that
+// handled the first attempt.
+type idempotencyCache struct {
+	mu      sync.Mutex
+	entries map[string]*idempotentResponse
+
+	// pending and done hold the entries of requests in progress and of
+	// finished ones. Every entry lives for idempotencyTTL, so each list is in
+	// order of expiry, from the front.
+	pending list.List
+	done    list.List
+}
+
+func newIdempotencyCache() *idempotencyCache {
+	return &idempotencyCache{entries: make(map[string]*idempotentResponse)}
+}
+
+// middleware replays the remembered response for a repeated Idempotency-Key.
+// A key reused with a different body is rejected with 422, and a retry while
+// the first attempt is still running with 409. Server errors are not
+// remembered, so they can be retried.
+func (c *idempotencyCache) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			writeError(w, r, "Idempotency-Key is too long", http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, r, "Could not read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		hash := sha256.Sum256(body)
+
+		entry, ok := c.begin(key, hash)
+		if !ok {
+			switch {
+			case entry.bodyHash != hash:
+				writeErrorCode(w, r, "Idempotency-Key was already used for a different request", codeIdempotencyKeyReused, http.StatusUnprocessableEntity)
+			case !entry.done:
+				writeErrorCode(w, r, "A request with this Idempotency-Key is in progress", codeIdempotencyKeyInUse, http.StatusConflict)
+			default:
+				for name, values := range entry.header {
+					w.Header()[name] = values
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(entry.status)
+				_, _ = w.Write(entry.body)
+			}
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		c.finish(key, rec)
+	})
+}
+
+// begin reserves key for a new request, or returns the existing entry and
+// false if the key is already known.
+func (c *idempotencyCache) begin(key string, hash [sha256.Size]byte) (idempotentResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if entry, ok := c.entries[key]; ok {
+		if now.Before(entry.expires) {
+			return *entry, false
+		}
+		c.remove(entry)
+	}
+
+	c.removeExpired(now)
+	if len(c.entries) >= maxIdempotencyEntries {
+		c.evictOldest()
+	}
+
+	entry := &idempotentResponse{key: key, bodyHash: hash, expires: now.Add(idempotencyTTL)}
+	entry.elem = c.pending.PushBack(entry)
+	c.entries[key] = entry
+	return idempotentResponse{}, true
+}
+
+// removeExpired forgets the keys that expired by now. The caller must hold
+// c.mu.
+func (c *idempotencyCache) removeExpired(now time.Time) {
+	for _, l := range []*list.List{&c.pending, &c.done} {
+		for l.Len() != 0 {
+			entry := l.Front().Value.(*idempotentResponse)
+			if now.Before(entry.expires) {
+				break
+			}
+			c.remove(entry)
+		}
+	}
+}
+
+// evictOldest forgets the key closest to expiring, preferring finished
+// requests over ones still in progress. The caller must hold c.mu.
+func (c *idempotencyCache) evictOldest() {
+	oldest := c.done.Front()
+	if oldest == nil {
+		oldest = c.pending.Front()
+	}
+	if oldest == nil {
+		return
+	}
+
+	c.remove(oldest.Value.(*idempotentResponse))
+	metrics.IncIdempotencyEvictions()
+	slog.Warn("idempotency cache full, evicted oldest key", slog.Int("max_entries", maxIdempotencyEntries))
+}
+
+// remove forgets entry. The caller must hold c.mu.
+func (c *idempotencyCache) remove(entry *idempotentResponse) {
+	if entry.done {
+		c.done.Remove(entry.elem)
+	} else {
+		c.pending.Remove(entry.elem)
+	}
+	delete(c.entries, entry.key)
+}
+
+// finish remembers the response recorded for key, or forgets the key if the
+// request failed with a server error.
+func (c *idempotencyCache) finish(key string, rec *responseRecorder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	if rec.status >= http.StatusInternalServerError {
+		c.remove(entry)
+		return
+	}
+
+	c.pending.Remove(entry.elem)
+	entry.done = true
+	entry.status = rec.status
+	entry.header = rec.Header().Clone()
+	entry.body = rec.body.Bytes()
+	entry.expires = time.Now().Add(idempotencyTTL)
+	entry.elem = c.done.PushBack(entry)
+}
+
+// responseRecorder passes a response through while keeping a copy of its
+// status and body.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+// WriteHeader records the status code before passing it on.
+func (rec *responseRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// Write copies the body before passing it on.
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying ResponseWriter for use by http.ResponseController.
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func finishOK(c *idempotencyCache, key string) {
+	c.finish(key, &responseRecorder{ResponseWriter: httptest.NewRecorder(), status: http.StatusOK})
+}
+
+func TestIdempotencyCacheEvictsOldestWhenFull(t *testing.T) {
+	c := newIdempotencyCache()
+	hash := sha256.Sum256(nil)
+
+	for i := range maxIdempotencyEntries {
+		if _, ok := c.begin(fmt.Sprint(i), hash); !ok {
+			t.Fatalf("key %d unexpectedly known", i)
+		}
+		// Key 0 stays in progress, so finished key 1 is evicted first.
+		if i != 0 {
+			finishOK(c, fmt.Sprint(i))
+		}
+	}
+
+	if _, ok := c.begin("new", hash); !ok {
+		t.Fatal("new key rejected")
+	}
+	if len(c.entries) != maxIdempotencyEntries {
+		t.Errorf("cache holds %d entries, want %d", len(c.entries), maxIdempotencyEntries)
+	}
+	if _, ok := c.entries["new"]; !ok {
+		t.Error("new key was not stored")
+	}
+	if _, ok := c.entries["1"]; ok {
+		t.Error("oldest finished key was not evicted")
+	}
+	if _, ok := c.entries["0"]; !ok {
+		t.Error("key in progress was evicted before finished keys")
+	}
+	if _, ok := c.begin("new", hash); ok {
+		t.Error("new key not recognized on retry")
+	}
+}
+
+func TestIdempotencyCacheForgetsExpiredKeys(t *testing.T) {
+	c := newIdempotencyCache()
+	hash := sha256.Sum256(nil)
+
+	c.begin("done", hash)
+	finishOK(c, "done")
+	c.begin("pending", hash)
+	c.begin("fresh", hash)
+	past := time.Now().Add(-time.Second)
+	c.entries["done"].expires = past
+	c.entries["pending"].expires = past
+
+	if _, ok := c.begin("new", hash); !ok {
+		t.Fatal("new key rejected")
+	}
+	for key, want := range map[string]bool{"done": false, "pending": false, "fresh": true, "new": true} {
+		if _, ok := c.entries[key]; ok != want {
+			t.Errorf("key %q stored = %v, want %v", key, ok, want)
+		}
+	}
+	if n := c.pending.Len() + c.done.Len(); n != len(c.entries) {
+		t.Errorf("lists hold %d entries, map holds %d", n, len(c.entries))
+	}
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lucasmcclean/limitlink/link"
+)
+
+// continueParam marks a request that continues past the interstitial page. Its
+// value is a token issued by the page, naming the chosen variant, if any, so
+// the visitor ends up where the page said.
+const continueParam = "ll_continue"
+
+// continueTokenTTL is how long the continue link of an interstitial page stays
+// valid after the page is shown.
+const continueTokenTTL = 10 * time.Minute
+
+// processSecret signs continue tokens when no secret is configured. Tokens
+// signed with it don't survive a restart or work across instances.
+var processSecret = sync.OnceValue(func() []byte {
+	secret := make([]byte, 32)
+	_, _ = rand.Read(secret)
+	return secret
+})
+
+// InterstitialPolicy shows an interstitial page for targets outside a list of
+// allowed domains, unless the link has its own interstitial.
+type InterstitialPolicy struct {
+	// AllowedDomains are the domains, including their subdomains, that are
+	// redirected to directly. When empty, no server-wide page is shown.
+	AllowedDomains []string
+
+	// Countdown is the number of seconds before continuing automatically.
+	Countdown int
+
+	// Notice is optional text shown on the page.
+	Notice string
+
+	// Secret signs the continue links of interstitial pages. When empty, a
+	// random secret is generated for the lifetime of the process.
+	Secret []byte
+}
+
+// secret returns the key continue tokens are signed with.
+func (p InterstitialPolicy) secret() []byte {
+	if len(p.Secret) != 0 {
+		return p.Secret
+	}
+	return processSecret()
+}
+
+// continueToken returns a token letting a visitor continue past the
+// interstitial page of slug to variant ("" for none) until expires.
+func (p InterstitialPolicy) continueToken(slug, variant string, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	return exp + "." + variant + "." + p.sign(slug, variant, exp)
+}
+
+// continued reports whether r carries a valid, unexpired continue token for
+// slug, and returns the variant named in it.
+func (p InterstitialPolicy) continued(r *http.Request, slug string, now time.Time) (variant string, ok bool) {
+	token := r.URL.Query().Get(continueParam)
+	exp, rest, found := strings.Cut(token, ".")
+	if !found {
+		return "", false
+	}
+	i := strings.LastIndexByte(rest, '.')
+	if i < 0 {
+		return "", false
+	}
+	variant, mac := rest[:i], rest[i+1:]
+
+	if !hmac.Equal([]byte(mac), []byte(p.sign(slug, variant, exp))) {
+		return "", false
+	}
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || now.Unix() > expires {
+		return "", false
+	}
+	return variant, true
+}
+
+// sign returns the encoded HMAC of the fields of a continue token.
+func (p InterstitialPolicy) sign(slug, variant, exp string) string {
+	mac := hmac.New(sha256.New, p.secret())
+	mac.Write([]byte(slug + "\x00" + variant + "\x00" + exp))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// interstitialFor returns the interstitial to show before redirecting to
+// target, or nil if the visitor should be redirected directly.
+func interstitialFor(lnk *link.Link, target string, policy InterstitialPolicy) *link.Interstitial {
+	if lnk.Interstitial != nil {
+		return lnk.Interstitial
+	}
+	if len(policy.AllowedDomains) == 0 {
+		return nil
+	}
+
+	host := ""
+	if parsed, err := url.Parse(target); err == nil {
+		host = parsed.Hostname()
+	}
+	if slices.ContainsFunc(policy.AllowedDomains, func(domain string) bool {
+		return link.MatchesDomain(host, domain)
+	}) {
+		return nil
+	}
+	return &link.Interstitial{Countdown: policy.Countdown, Notice: policy.Notice}
+}
+
+// renderInterstitial writes the interstitial page for target. The continue
+// link repeats the request with continueParam set to a token naming the
+// chosen variant.
+func renderInterstitial(w http.ResponseWriter, r *http.Request, slug, target string, variant *link.Variant, page *link.Interstitial, policy InterstitialPolicy, now time.Time) {
+	host := target
+	if parsed, err := url.Parse(target); err == nil {
+		host = parsed.Hostname()
+	}
+
+	continueURL := *r.URL
+	query := continueURL.Query()
+	name := ""
+	if variant != nil {
+		name = variant.Name
+	}
+	query.Set(continueParam, policy.continueToken(slug, name, now.Add(continueTokenTTL)))
+	continueURL.RawQuery = query.Encode()
+
+	data := struct {
+		Host        string
+		Notice      string
+		Countdown   int
+		ContinueURL string
+	}{
+		Host:        host,
+		Notice:      page.Notice,
+		Countdown:   page.Countdown,
+		ContinueURL: continueURL.RequestURI(),
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := templates.ExecuteTemplate(w, "interstitial.html", data); err != nil {
+		slog.ErrorContext(r.Context(), "error rendering interstitial", slog.Any("error", err))
+	}
+}
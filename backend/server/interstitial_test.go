@@ -0,0 +1,109 @@
+package server
+
+import (
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lucasmcclean/limitlink/link"
+	"github.com/lucasmcclean/limitlink/link/linktest"
+)
+
+func continueRequest(token string) *http.Request {
+	return httptest.NewRequest(http.MethodGet, "/abcdef?"+url.Values{continueParam: {token}}.Encode(), nil)
+}
+
+func TestContinueToken(t *testing.T) {
+	policy := InterstitialPolicy{Secret: []byte("secret")}
+	now := time.Unix(1_700_000_000, 0)
+	valid := policy.continueToken("abcdef", "B", now.Add(time.Minute))
+
+	tests := []struct {
+		name    string
+		token   string
+		slug    string
+		now     time.Time
+		ok      bool
+		variant string
+	}{
+		{"valid", valid, "abcdef", now, true, "B"},
+		{"without variant", policy.continueToken("abcdef", "", now.Add(time.Minute)), "abcdef", now, true, ""},
+		{"variant with dots", policy.continueToken("abcdef", "v.1", now.Add(time.Minute)), "abcdef", now, true, "v.1"},
+		{"expired", valid, "abcdef", now.Add(2 * time.Minute), false, ""},
+		{"other slug", valid, "xyz", now, false, ""},
+		{"other secret", InterstitialPolicy{Secret: []byte("other")}.continueToken("abcdef", "B", now.Add(time.Minute)), "abcdef", now, false, ""},
+		{"forged variant", unix(now.Add(time.Minute)) + ".A." + policy.sign("abcdef", "B", unix(now.Add(time.Minute))), "abcdef", now, false, ""},
+		{"extended expiry", unix(now.Add(time.Hour)) + ".B." + policy.sign("abcdef", "B", unix(now.Add(time.Minute))), "abcdef", now.Add(2 * time.Minute), false, ""},
+		{"bare variant", "B", "abcdef", now, false, ""},
+		{"empty", "", "abcdef", now, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variant, ok := policy.continued(continueRequest(tt.token), tt.slug, tt.now)
+			if ok != tt.ok || variant != tt.variant {
+				t.Errorf("continued = %q, %v, want %q, %v", variant, ok, tt.variant, tt.ok)
+			}
+		})
+	}
+}
+
+func unix(t time.Time) string {
+	return strconv.FormatInt(t.Unix(), 10)
+}
+
+var continueLink = regexp.MustCompile(`href="([^"]*` + continueParam + `[^"]*)"`)
+
+func TestInterstitialContinue(t *testing.T) {
+	repo := linktest.NewRepository()
+	now := time.Now()
+	repo.Add(&link.Link{
+		Slug:      "abcdef",
+		Target:    "https://example.com/a",
+		Variants:  []link.Variant{{Name: "A", Target: "https://example.com/a", Weight: 1}, {Name: "B", Target: "https://example.com/b", Weight: 1}},
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Hour),
+		Interstitial: &link.Interstitial{
+			Countdown: 5,
+		},
+	})
+	handler := RedirectHandler(repo, Config{RedirectCode: http.StatusFound})
+
+	t.Run("forged parameter shows the page", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/abcdef?"+continueParam+"=B", nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("status = %d, want the interstitial page", rec.Code)
+		}
+	})
+
+	t.Run("issued token redirects to the chosen variant", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/abcdef", nil))
+		match := continueLink.FindStringSubmatch(rec.Body.String())
+		if match == nil {
+			t.Fatalf("no continue link in page:\n%s", rec.Body)
+		}
+		continueURL, err := url.Parse(html.UnescapeString(match[1]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, rest, _ := strings.Cut(continueURL.Query().Get(continueParam), ".")
+		variant, _, _ := strings.Cut(rest, ".")
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, continueURL.String(), nil))
+		if rec.Code != http.StatusFound {
+			t.Fatalf("status = %d, want %d", rec.Code, http.StatusFound)
+		}
+		if want := "https://example.com/" + map[string]string{"A": "a", "B": "b"}[variant]; rec.Header().Get("Location") != want {
+			t.Errorf("Location = %q, want %q", rec.Header().Get("Location"), want)
+		}
+	})
+}
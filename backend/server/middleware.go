@@ -1,8 +1,35 @@
 package server
 
-import "net/http"
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
-const maxBodyBytes = 1 << 16
+	"github.com/lucasmcclean/limitlink/logging"
+	"github.com/lucasmcclean/limitlink/metrics"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	maxBodyBytes = 1 << 16
+
+	// requestIDHeader is the header used to receive and propagate request IDs.
+	requestIDHeader = "X-Request-ID"
+
+	// maxRequestIDLen is the longest incoming request ID that will be propagated.
+	maxRequestIDLen = 128
+
+	// redacted replaces secrets in access logs.
+	redacted = "REDACTED"
+)
+
+// sensitiveParams are query parameters whose values are never logged.
+var sensitiveParams = []string{"password", "token", "adminToken"}
 
 // maxBodySizeMiddleware limits the size of request bodies to protect against DoS.
 // - If Content-Length > maxBodyBytes: rejects with 413.
@@ -19,3 +46,139 @@ func maxBodySizeMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// methodLabel returns method if the server handles it, or "other", so
+// arbitrary methods sent by clients can't create new metric series or span
+// names.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return method
+	default:
+		return "other"
+	}
+}
+
+// metricsMiddleware records the count, latency, and in-flight number of
+// requests served by next under the given route label.
+func metricsMiddleware(route string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		done := metrics.RequestStarted(route, methodLabel(r.Method))
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() { done(rec.status) }()
+
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// spanNameMiddleware names the current server span after route and records
+// the route as an attribute.
+func spanNameMiddleware(route string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span := trace.SpanFromContext(r.Context())
+		span.SetName(methodLabel(r.Method) + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route))
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+// WriteHeader records the status code before passing it on.
+func (rec *statusRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap returns the underlying ResponseWriter for use by http.ResponseController.
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// requestIDMiddleware propagates a valid incoming X-Request-ID or assigns a new
+// one. The ID is echoed in the response and stored in the request context.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !isValidRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(requestIDHeader, id)
+
+		ctx := logging.WithRequestID(r.Context(), id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// accessLogMiddleware logs one line per request with admin tokens and
+// passwords redacted.
+func accessLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		slog.InfoContext(r.Context(), "request handled",
+			slog.String("method", r.Method),
+			slog.String("path", redactPath(r.URL.Path)),
+			slog.String("query", redactQuery(r.URL.Query())),
+			slog.Int("status", rec.status),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("remote_addr", r.RemoteAddr),
+			slog.String("user_agent", r.UserAgent()),
+		)
+	})
+}
+
+// isValidRequestID reports whether id is safe to propagate and log.
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, c := range id {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random 128-bit hex-encoded request ID.
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// redactPath hides the admin token in paths of the form /links/admin-token.
+func redactPath(path string) string {
+	if rest, ok := strings.CutPrefix(path, "/links/"); ok && rest != "" {
+		if _, sub, found := strings.Cut(rest, "/"); found {
+			return "/links/" + redacted + "/" + sub
+		}
+		return "/links/" + redacted
+	}
+	return path
+}
+
+// redactQuery encodes the query with the values of sensitive parameters hidden.
+func redactQuery(query url.Values) string {
+	for _, key := range sensitiveParams {
+		if query.Has(key) {
+			query.Set(key, redacted)
+		}
+	}
+	return query.Encode()
+}
@@ -0,0 +1,24 @@
+package server
+
+import "testing"
+
+func TestMethodLabel(t *testing.T) {
+	tests := map[string]string{
+		"GET":     "GET",
+		"HEAD":    "HEAD",
+		"POST":    "POST",
+		"PATCH":   "PATCH",
+		"DELETE":  "DELETE",
+		"OPTIONS": "OPTIONS",
+		"PUT":     "other",
+		"TRACE":   "other",
+		"get":     "other",
+		"BREW":    "other",
+		"":        "other",
+	}
+	for method, want := range tests {
+		if got := methodLabel(method); got != want {
+			t.Errorf("methodLabel(%q) = %q, want %q", method, got, want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lucasmcclean/limitlink/link"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+// templates holds the server-rendered HTML pages.
+var templates = template.Must(template.ParseFS(templateFS, "templates/*.html"))
+
+// previewSuffix marks a request for the preview of a link, as in /slug+.
+const previewSuffix = "+"
+
+// statusText describes each link status on the preview page.
+var statusText = map[link.Status]string{
+	link.StatusAvailable:   "Available",
+	link.StatusNotYetValid: "Not open yet",
+	link.StatusExpired:     "Expired",
+	link.StatusExhausted:   "No uses left",
+	link.StatusClosed:      "Closed right now",
+	link.StatusDisabled:    "Disabled",
+}
+
+// isPreview reports whether r asks to inspect a link instead of following it,
+// either with the /slug+ form or with ?preview=1.
+func isPreview(r *http.Request, segment string) bool {
+	if strings.HasSuffix(segment, previewSuffix) {
+		return true
+	}
+	preview, _ := strconv.ParseBool(r.URL.Query().Get("preview"))
+	return preview
+}
+
+// renderPreview writes a page describing where a link leads and how long it
+// remains valid, without counting a hit. Destinations of password-protected
+// links, disabled links and links the visitor may not use, because of their
+// network, country or referrer, are not revealed.
+func renderPreview(w http.ResponseWriter, r *http.Request, lnk *link.Link, cfg Config, now time.Time) {
+	status := lnk.Status(now)
+	expiresAt := lnk.EffectiveExpiresAt()
+
+	data := struct {
+		Slug          string
+		Hosts         []string
+		Hidden        bool
+		Password      bool
+		Available     bool
+		Status        string
+		OpensAt       string
+		ExpiresAt     string
+		ExpiresIn     string
+		RemainingHits string
+	}{
+		Slug:      lnk.Slug,
+		Hidden:    lnk.PasswordHash != nil || status == link.StatusDisabled || !lnk.Allows(requestInfo(r, cfg), cfg.NoReferrer),
+		Password:  lnk.PasswordHash != nil,
+		Available: status == link.StatusAvailable,
+		Status:    statusText[status],
+		ExpiresAt: expiresAt.UTC().Format(time.RFC1123),
+		ExpiresIn: formatRemaining(expiresAt.Sub(now)),
+	}
+	if !data.Hidden {
+		data.Hosts = lnk.DestinationHosts()
+	}
+	if status == link.StatusNotYetValid || status == link.StatusClosed {
+		if next := lnk.NextOpening(now); next != nil {
+			data.OpensAt = next.UTC().Format(time.RFC1123)
+		}
+	}
+	if remaining := lnk.RemainingHits(); remaining != nil {
+		data.RemainingHits = strconv.Itoa(*remaining)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := templates.ExecuteTemplate(w, "preview.html", data); err != nil {
+		slog.ErrorContext(r.Context(), "error rendering preview", slog.String("slug", lnk.Slug), slog.Any("error", err))
+	}
+}
+
+// formatRemaining formats a duration in days, hours and minutes, such as
+// "in 2d 3h" or "expired".
+func formatRemaining(d time.Duration) string {
+	if d <= 0 {
+		return "expired"
+	}
+
+	days := int(d / (24 * time.Hour))
+	hours := int(d % (24 * time.Hour) / time.Hour)
+	minutes := int(d % time.Hour / time.Minute)
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("in %dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("in %dh %dm", hours, minutes)
+	default:
+		return fmt.Sprintf("in %dm", max(minutes, 1))
+	}
+}
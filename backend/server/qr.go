@@ -0,0 +1,91 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/lucasmcclean/limitlink/qrcode"
+)
+
+// qrOptions reads the QR code options from the query parameters of r:
+//   - size: width and height in pixels
+//   - ecl: error correction level (L, M, Q, H)
+//   - margin: quiet zone in modules
+//   - fg, bg: hex RGB colors of dark and light modules
+func qrOptions(r *http.Request) (qrcode.Options, error) {
+	opts := qrcode.DefaultOptions()
+	query := r.URL.Query()
+	var err error
+
+	if v := query.Get("size"); v != "" {
+		if opts.Size, err = strconv.Atoi(v); err != nil {
+			return opts, qrcode.ErrInvalidSize
+		}
+	}
+	if v := query.Get("ecl"); v != "" {
+		if opts.Level, err = qrcode.ParseLevel(v); err != nil {
+			return opts, err
+		}
+	}
+	if v := query.Get("margin"); v != "" {
+		if opts.Margin, err = strconv.Atoi(v); err != nil {
+			return opts, qrcode.ErrInvalidMargin
+		}
+	}
+	if v := query.Get("fg"); v != "" {
+		if opts.Foreground, err = qrcode.ParseColor(v); err != nil {
+			return opts, err
+		}
+	}
+	if v := query.Get("bg"); v != "" {
+		if opts.Background, err = qrcode.ParseColor(v); err != nil {
+			return opts, err
+		}
+	}
+
+	return opts, opts.Validate()
+}
+
+// serveQR writes a QR code of the short URL for slug as a PNG, or as an SVG
+// when the format query parameter is "svg". It never counts a hit.
+func serveQR(w http.ResponseWriter, r *http.Request, slug string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	opts, err := qrOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var image []byte
+	var contentType string
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "png":
+		image, err = qrcode.PNG(shortURL(slug), opts)
+		contentType = "image/png"
+	case "svg":
+		image, err = qrcode.SVG(shortURL(slug), opts)
+		contentType = "image/svg+xml"
+	default:
+		http.Error(w, "format must be png or svg", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "error encoding QR code", slog.String("slug", slug), slog.Any("error", err))
+		http.Error(w, "Error encoding QR code", http.StatusInternalServerError)
+		return
+	}
+
+	// The short URL of a slug never changes, so the image can be cached.
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(image)))
+	if _, err := w.Write(image); err != nil {
+		slog.DebugContext(r.Context(), "error writing QR code", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lucasmcclean/limitlink/link"
+)
+
+// revisionsHandler routes requests below /links/admin-token:
+//   - GET  /links/admin-token/revisions: list past revisions
+//   - POST /links/admin-token/revisions/{number}/rollback: restore a revision
+func revisionsHandler(w http.ResponseWriter, r *http.Request, links link.Repository, sub []string) {
+	switch {
+	case len(sub) == 1 && sub[0] == "revisions":
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		listRevisions(w, r, links)
+
+	case len(sub) == 3 && sub[0] == "revisions" && sub[2] == "rollback":
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		number, err := strconv.Atoi(sub[1])
+		if err != nil || number < 0 {
+			writeError(w, r, "Invalid revision number", http.StatusBadRequest)
+			return
+		}
+		rollbackLink(w, r, links, number)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// listRevisions returns the past revisions of a link, newest first.
+// It expects the admin token in the URL path to authorize the request.
+func listRevisions(w http.ResponseWriter, r *http.Request, links link.Repository) {
+	adminToken, err := extractAdminToken(r)
+	if err != nil {
+		writeLinkError(w, r, err, http.StatusUnauthorized)
+		return
+	}
+
+	revisions, err := links.ListRevisionsByToken(r.Context(), adminToken)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "error listing revisions", slog.Any("error", err))
+		writeError(w, r, "Error retrieving revisions", http.StatusInternalServerError)
+		return
+	}
+	if revisions == nil {
+		writeError(w, r, "Link not found or invalid admin token", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(revisions); err != nil {
+		slog.ErrorContext(r.Context(), "error serializing revisions", slog.Any("error", err))
+		writeError(w, r, "Error serializing revisions", http.StatusInternalServerError)
+	}
+}
+
+// rollbackLink restores a link to the state recorded in the given revision.
+// The state being replaced is itself recorded as a new revision.
+func rollbackLink(w http.ResponseWriter, r *http.Request, links link.Repository, number int) {
+	adminToken, err := extractAdminToken(r)
+	if err != nil {
+		writeLinkError(w, r, err, http.StatusUnauthorized)
+		return
+	}
+
+	original, err := links.GetByToken(r.Context(), adminToken)
+	if err != nil || original == nil {
+		writeError(w, r, "Link not found or invalid admin token", http.StatusNotFound)
+		return
+	}
+
+	rev, err := links.GetRevisionByToken(r.Context(), adminToken, number)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "error retrieving revision", slog.Any("error", err))
+		writeError(w, r, "Error retrieving revision", http.StatusInternalServerError)
+		return
+	}
+	if rev == nil {
+		writeError(w, r, "Revision not found", http.StatusNotFound)
+		return
+	}
+
+	now := time.Now()
+	patch, err := link.ValidatePatch(original, link.RollbackPatch(original, rev, now), now)
+	if err != nil {
+		writeLinkError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := links.PatchByToken(r.Context(), adminToken, patch); err != nil {
+		if errors.Is(err, link.ErrConflict) {
+			writeLinkError(w, r, err, http.StatusConflict)
+			return
+		}
+		slog.ErrorContext(r.Context(), "error rolling back link", slog.Any("error", err))
+		writeError(w, r, "Error updating link", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
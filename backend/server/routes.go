@@ -4,16 +4,33 @@ import (
 	"net/http"
 
 	"github.com/lucasmcclean/limitlink/link"
+	"github.com/lucasmcclean/limitlink/metrics"
 )
 
-func registerRoutes(mux *http.ServeMux, repo link.Repository) {
-	mux.HandleFunc("/links", func(w http.ResponseWriter, r *http.Request) {
+func registerRoutes(mux *http.ServeMux, repo link.Repository, health *Health, cfg Config) {
+	idempotency := newIdempotencyCache()
+	mux.Handle("/links", route("/links", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodPost {
-			LinkHandler(repo)(w, r)
+			idempotency.middleware(LinkHandler(repo, cfg)).ServeHTTP(w, r)
 			return
 		}
 		http.NotFound(w, r)
-	})
-	mux.Handle("/links/", LinkHandler(repo))
-	mux.Handle("/", RedirectHandler(repo))
+	})))
+	mux.Handle("/links/", route("/links/{token}", LinkHandler(repo, cfg)))
+	mux.Handle("/", route("/{slug}", RedirectHandler(repo, cfg)))
+	mux.Handle("/healthz", LivenessHandler())
+	mux.Handle("/readyz", ReadinessHandler(health))
+
+	if cfg.MetricsAddr == "" {
+		mux.Handle("/metrics", metrics.Handler())
+	}
+}
+
+// route instruments handler with metrics and tracing under the given route name.
+func route(name string, handler http.Handler) http.Handler {
+	return metricsMiddleware(name, spanNameMiddleware(name, handler))
+}
+
+func registerAdminRoutes(mux *http.ServeMux) {
+	mux.Handle("/metrics", metrics.Handler())
 }
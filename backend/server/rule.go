@@ -0,0 +1,217 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lucasmcclean/limitlink/link"
+)
+
+// requestInfo extracts the properties routing rules and access restrictions
+// match on from r.
+func requestInfo(r *http.Request, cfg Config) link.RequestInfo {
+	ip := clientIP(r, cfg.TrustedProxies)
+	query := r.URL.Query()
+	query.Del(continueParam)
+	return link.RequestInfo{
+		Device:       deviceClass(r.UserAgent()),
+		Language:     preferredLanguage(r.Header.Get("Accept-Language")),
+		ReferrerHost: referrerHost(r.Referer()),
+		Country:      clientCountry(ip, cfg.Countries),
+		IP:           ip,
+		Path:         pathSuffix(r.URL.Path),
+		Query:        query,
+	}
+}
+
+// deviceClass classifies a User-Agent as ios, android, or desktop.
+// Anything not recognized as a mobile platform is treated as desktop.
+func deviceClass(userAgent string) string {
+	switch {
+	case strings.Contains(userAgent, "iPhone"), strings.Contains(userAgent, "iPad"), strings.Contains(userAgent, "iPod"):
+		return link.DeviceIOS
+	case strings.Contains(userAgent, "Android"):
+		return link.DeviceAndroid
+	default:
+		return link.DeviceDesktop
+	}
+}
+
+// preferredLanguage returns the language tag with the highest quality value in
+// an Accept-Language header, or "" if there is none.
+func preferredLanguage(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > bestQ {
+			best, bestQ = tag, q
+		}
+	}
+	return best
+}
+
+// referrerHost returns the host name of a Referer header, or "" if it is
+// missing or malformed.
+func referrerHost(referrer string) string {
+	if referrer == "" {
+		return ""
+	}
+	parsed, err := url.Parse(referrer)
+	if err != nil {
+		return ""
+	}
+	return parsed.Hostname()
+}
+
+// dryRun handles POST /links/admin-token/dry-run, reporting which target a
+// request with the given properties would be redirected to without counting
+// a hit, and whether its network, country, and referrer may use the link. Omitted properties are
+// taken from the dry-run request itself, except the path after the slug.
+func dryRun(w http.ResponseWriter, r *http.Request, links link.Repository, cfg Config) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	adminToken, err := extractAdminToken(r)
+	if err != nil {
+		writeLinkError(w, r, err, http.StatusUnauthorized)
+		return
+	}
+
+	var input struct {
+		UserAgent      *string `json:"userAgent"`
+		AcceptLanguage *string `json:"acceptLanguage"`
+		Referrer       *string `json:"referrer"`
+		Country        *string `json:"country"`
+		IP             *string `json:"ip"`
+		Query          *string `json:"query"`
+		Path           *string `json:"path"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, r, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	lnk, err := links.GetByToken(r.Context(), adminToken)
+	if err != nil || lnk == nil {
+		writeError(w, r, "Link not found or invalid admin token", http.StatusNotFound)
+		return
+	}
+
+	info := requestInfo(r, cfg)
+	if input.UserAgent != nil {
+		info.Device = deviceClass(*input.UserAgent)
+	}
+	if input.AcceptLanguage != nil {
+		info.Language = preferredLanguage(*input.AcceptLanguage)
+	}
+	if input.Referrer != nil {
+		info.ReferrerHost = referrerHost(*input.Referrer)
+	}
+	if input.IP != nil {
+		ip, err := netip.ParseAddr(*input.IP)
+		if err != nil {
+			writeError(w, r, "Invalid IP address", http.StatusBadRequest)
+			return
+		}
+		info.IP = ip.Unmap()
+		info.Country = clientCountry(info.IP, cfg.Countries)
+	}
+	if input.Country != nil {
+		info.Country = *input.Country
+	}
+	if input.Query != nil {
+		query, err := url.ParseQuery(strings.TrimPrefix(*input.Query, "?"))
+		if err != nil {
+			writeError(w, r, "Invalid query string", http.StatusBadRequest)
+			return
+		}
+		info.Query = query
+	}
+
+	resp := struct {
+		Device       string      `json:"device"`
+		Language     string      `json:"language,omitempty"`
+		ReferrerHost string      `json:"referrerHost,omitempty"`
+		Country      string      `json:"country,omitempty"`
+		IP           string      `json:"ip,omitempty"`
+		Allowed      bool        `json:"allowed"`
+		Rule         *int        `json:"rule"`
+		Target       string      `json:"target,omitempty"`
+		Variants     []string    `json:"variants,omitempty"`
+		Error        string      `json:"error,omitempty"`
+		Status       link.Status `json:"status"`
+	}{
+		Device:       info.Device,
+		Language:     info.Language,
+		ReferrerHost: info.ReferrerHost,
+		Country:      info.Country,
+		IP:           ipString(info.IP),
+		Allowed:      lnk.Allows(info, cfg.NoReferrer),
+		Status:       lnk.Status(time.Now()),
+	}
+
+	if i, rule := lnk.MatchRule(info); rule != nil {
+		resp.Rule = &i
+		resp.Target = rule.Target
+	} else if target, ok := lnk.CountryTarget(info.Country); ok {
+		resp.Target = target
+	} else if len(lnk.Variants) != 0 {
+		for _, v := range lnk.Variants {
+			resp.Variants = append(resp.Variants, v.Name)
+		}
+	} else {
+		resp.Target = lnk.Target
+	}
+
+	if resp.Target != "" {
+		info.Path = nil
+		if input.Path != nil {
+			info.Path = pathSuffix("/" + lnk.Slug + "/" + strings.TrimPrefix(*input.Path, "/"))
+		}
+		rendered := link.RenderTarget(resp.Target, info, time.Now())
+		forwarded, err := lnk.Tag(rendered, cfg.Campaign)
+		if err == nil {
+			forwarded, err = lnk.Forward(forwarded, info.Path, info.Query)
+		}
+		if err != nil {
+			resp.Error = err.Error()
+		} else {
+			resp.Target = forwarded
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.ErrorContext(r.Context(), "error encoding dry run", slog.Any("error", err))
+		writeError(w, r, "Error encoding dry run", http.StatusInternalServerError)
+	}
+}
+
+// ipString formats ip, returning "" for the zero Addr.
+func ipString(ip netip.Addr) string {
+	if !ip.IsValid() {
+		return ""
+	}
+	return ip.String()
+}
@@ -1,18 +1,151 @@
 package server
 
 import (
+	"fmt"
+	"log/slog"
 	"net/http"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/lucasmcclean/limitlink/link"
+	"github.com/lucasmcclean/limitlink/metrics"
+	"github.com/lucasmcclean/limitlink/tracing"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
-func New(repo link.Repository) *http.Server {
+// defaultCountdown is the default number of seconds before an interstitial
+// page continues automatically.
+const defaultCountdown = 5
+
+// Config holds the settings used to build the HTTP servers.
+type Config struct {
+	// MetricsAddr is the address of a separate admin listener serving /metrics.
+	// When empty, /metrics is served by the main server instead.
+	MetricsAddr string
+
+	// Countries resolves client IP addresses to countries for per-link
+	// country restrictions and targets. When nil, the country of every
+	// visitor is unknown.
+	Countries CountryLookup
+
+	// TrustedProxies are the networks of reverse proxies whose
+	// X-Forwarded-For entries are trusted when determining the client IP.
+	TrustedProxies []netip.Prefix
+
+	// NoReferrer is the default policy for requests without a Referer header
+	// to links that restrict referrers.
+	NoReferrer link.ReferrerPolicy
+
+	// Campaign holds the default UTM parameters for links with a campaign.
+	Campaign link.Campaign
+
+	// RedirectCode is the status code of redirects for links without their
+	// own redirect code.
+	RedirectCode int
+
+	// Interstitial decides which targets get an interstitial page when the
+	// link doesn't configure one.
+	Interstitial InterstitialPolicy
+}
+
+// CountryLookup resolves IP addresses to ISO 3166-1 alpha-2 country codes,
+// returning "" for unknown addresses.
+type CountryLookup interface {
+	Country(ip netip.Addr) string
+}
+
+// ConfigFromEnv builds a Config from environment variables.
+//   - METRICS_ADDR: optional address of the admin listener (e.g. ":9090")
+//   - TRUSTED_PROXIES: optional comma-separated networks or IP addresses of
+//     reverse proxies (e.g. "10.0.0.0/8,127.0.0.1")
+//   - NO_REFERRER_POLICY: allow or deny (default: allow) requests without a
+//     Referer header to links that restrict referrers
+//   - CAMPAIGN_DEFAULTS: optional default UTM parameters for links with a
+//     campaign, as a query string (e.g. "utm_source=limitlink&utm_campaign={slug}")
+//   - REDIRECT_CODE: 302, 303, or 307 (default: 302) for links without their
+//     own redirect code
+//   - INTERSTITIAL_ALLOWED_DOMAINS: optional comma-separated domains; targets
+//     outside of them get an interstitial page
+//   - INTERSTITIAL_COUNTDOWN: seconds before the page continues automatically
+//     (default: 5; 0 waits for the visitor)
+//   - INTERSTITIAL_NOTICE: optional text shown on the page
+//   - INTERSTITIAL_SECRET: optional key signing the continue links of the
+//     page; set it when running several instances (default: random per process)
+func ConfigFromEnv() (Config, error) {
+	trusted, err := parsePrefixes(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+
+	noReferrer := link.ReferrerPolicy(strings.ToLower(os.Getenv("NO_REFERRER_POLICY")))
+	switch noReferrer {
+	case "":
+		noReferrer = link.ReferrerAllow
+	case link.ReferrerAllow, link.ReferrerDeny:
+	default:
+		return Config{}, fmt.Errorf("invalid NO_REFERRER_POLICY %q: must be allow or deny", noReferrer)
+	}
+
+	campaign, err := link.ParseCampaign(os.Getenv("CAMPAIGN_DEFAULTS"))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid CAMPAIGN_DEFAULTS: %w", err)
+	}
+
+	redirectCode := http.StatusFound
+	if value := os.Getenv("REDIRECT_CODE"); value != "" {
+		redirectCode, err = strconv.Atoi(value)
+		if err != nil || !link.IsRedirectCode(redirectCode) {
+			return Config{}, fmt.Errorf("invalid REDIRECT_CODE %q: must be 302, 303, or 307", value)
+		}
+	}
+
+	countdown := defaultCountdown
+	if value := os.Getenv("INTERSTITIAL_COUNTDOWN"); value != "" {
+		countdown, err = strconv.Atoi(value)
+		if err != nil || countdown < 0 {
+			return Config{}, fmt.Errorf("invalid INTERSTITIAL_COUNTDOWN %q: must be a number of seconds", value)
+		}
+	}
+
+	var allowedDomains []string
+	for _, domain := range strings.Split(os.Getenv("INTERSTITIAL_ALLOWED_DOMAINS"), ",") {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			allowedDomains = append(allowedDomains, domain)
+		}
+	}
+
+	return Config{
+		MetricsAddr:    os.Getenv("METRICS_ADDR"),
+		TrustedProxies: trusted,
+		NoReferrer:     noReferrer,
+		Campaign:       campaign,
+		RedirectCode:   redirectCode,
+		Interstitial: InterstitialPolicy{
+			AllowedDomains: allowedDomains,
+			Countdown:      countdown,
+			Notice:         os.Getenv("INTERSTITIAL_NOTICE"),
+			Secret:         []byte(os.Getenv("INTERSTITIAL_SECRET")),
+		},
+	}, nil
+}
+
+// New returns the public HTTP server. Every request is traced, continuing any
+// incoming W3C trace context, and all repository calls are instrumented with
+// metrics and tracing. Readiness is reported from health.
+func New(repo link.Repository, health *Health, cfg Config) *http.Server {
 	mux := http.NewServeMux()
 
-	registerRoutes(mux, repo)
+	registerRoutes(mux, metrics.NewRepository(tracing.NewRepository(repo)), health, cfg)
 
-	handler := maxBodySizeMiddleware(mux)
+	handler := requestIDMiddleware(accessLogMiddleware(maxBodySizeMiddleware(mux)))
+	handler = otelhttp.NewHandler(handler, "http.server",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return methodLabel(r.Method)
+		}),
+	)
 
 	return &http.Server{
 		Addr:              ":8080",
@@ -22,5 +155,29 @@ func New(repo link.Repository) *http.Server {
 		IdleTimeout:       120 * time.Second,
 		ReadHeaderTimeout: 5 * time.Second,
 		MaxHeaderBytes:    1 << 20,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelError),
+	}
+}
+
+// NewAdmin returns the admin HTTP server serving /metrics, or nil if no
+// separate admin listener is configured.
+func NewAdmin(cfg Config) *http.Server {
+	if cfg.MetricsAddr == "" {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+
+	registerAdminRoutes(mux)
+
+	return &http.Server{
+		Addr:              cfg.MetricsAddr,
+		Handler:           mux,
+		ReadTimeout:       5 * time.Second,
+		WriteTimeout:      10 * time.Second,
+		IdleTimeout:       120 * time.Second,
+		ReadHeaderTimeout: 5 * time.Second,
+		MaxHeaderBytes:    1 << 20,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelError),
 	}
 }
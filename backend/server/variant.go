@@ -0,0 +1,49 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/lucasmcclean/limitlink/link"
+)
+
+// variantCookiePrefix prefixes the cookie remembering a visitor's variant for
+// a link. The slug is appended to form the full cookie name.
+const variantCookiePrefix = "ll_variant_"
+
+// chooseVariant picks the variant of a split link to redirect to, or returns
+// nil if the link has a single target.
+//
+// A variant chosen before an interstitial page, named by the verified
+// continue token, is kept when the visitor continues. For sticky links, a variant remembered in the visitor's cookie is reused,
+// and a newly picked variant is remembered until the link expires.
+func chooseVariant(w http.ResponseWriter, r *http.Request, lnk *link.Link, continuedVariant string) *link.Variant {
+	if len(lnk.Variants) == 0 {
+		return nil
+	}
+
+	if variant := lnk.VariantByName(continuedVariant); variant != nil {
+		return variant
+	}
+
+	name := variantCookiePrefix + lnk.Slug
+	if lnk.StickyVariants {
+		if cookie, err := r.Cookie(name); err == nil {
+			if variant := lnk.VariantByName(cookie.Value); variant != nil {
+				return variant
+			}
+		}
+	}
+
+	variant := lnk.PickVariant()
+	if variant != nil && lnk.StickyVariants {
+		http.SetCookie(w, &http.Cookie{
+			Name:     name,
+			Value:    variant.Name,
+			Path:     "/" + lnk.Slug,
+			Expires:  lnk.EffectiveExpiresAt(),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+	return variant
+}
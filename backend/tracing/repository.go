@@ -0,0 +1,115 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/lucasmcclean/limitlink/link"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/lucasmcclean/limitlink/tracing")
+
+// Repository wraps a link.Repository and records a child span for every
+// operation.
+type Repository struct {
+	next link.Repository
+}
+
+// NewRepository returns a Repository that traces next.
+func NewRepository(next link.Repository) *Repository {
+	return &Repository{next: next}
+}
+
+// Create inserts a new link into the wrapped repository.
+func (r *Repository) Create(ctx context.Context, vLink *link.Validated) (err error) {
+	ctx, span := startSpan(ctx, "Create")
+	defer func() { endSpan(span, err) }()
+	return r.next.Create(ctx, vLink)
+}
+
+// GetBySlug retrieves a link by its public slug from the wrapped repository.
+func (r *Repository) GetBySlug(ctx context.Context, slug string) (lnk *link.Link, err error) {
+	ctx, span := startSpan(ctx, "GetBySlug", attribute.String("link.slug", slug))
+	defer func() { endSpan(span, err) }()
+	return r.next.GetBySlug(ctx, slug)
+}
+
+// IncBySlug increments the hit count for the given slug in the wrapped repository.
+func (r *Repository) IncBySlug(ctx context.Context, slug string) (err error) {
+	ctx, span := startSpan(ctx, "IncBySlug", attribute.String("link.slug", slug))
+	defer func() { endSpan(span, err) }()
+	return r.next.IncBySlug(ctx, slug)
+}
+
+// IncVariantBySlug increments the hit counts for the given slug and variant in the wrapped repository.
+func (r *Repository) IncVariantBySlug(ctx context.Context, slug, variant string) (err error) {
+	ctx, span := startSpan(ctx, "IncVariantBySlug",
+		attribute.String("link.slug", slug),
+		attribute.String("link.variant", variant),
+	)
+	defer func() { endSpan(span, err) }()
+	return r.next.IncVariantBySlug(ctx, slug, variant)
+}
+
+// IncFallbackBySlug increments the fallback hit count for the given slug in the wrapped repository.
+func (r *Repository) IncFallbackBySlug(ctx context.Context, slug string) (err error) {
+	ctx, span := startSpan(ctx, "IncFallbackBySlug", attribute.String("link.slug", slug))
+	defer func() { endSpan(span, err) }()
+	return r.next.IncFallbackBySlug(ctx, slug)
+}
+
+// GetByToken retrieves a link by its admin token from the wrapped repository.
+func (r *Repository) GetByToken(ctx context.Context, token string) (lnk *link.Link, err error) {
+	ctx, span := startSpan(ctx, "GetByToken")
+	defer func() { endSpan(span, err) }()
+	return r.next.GetByToken(ctx, token)
+}
+
+// DeleteByToken removes a link by its admin token from the wrapped repository.
+func (r *Repository) DeleteByToken(ctx context.Context, token string) (err error) {
+	ctx, span := startSpan(ctx, "DeleteByToken")
+	defer func() { endSpan(span, err) }()
+	return r.next.DeleteByToken(ctx, token)
+}
+
+// PatchByToken updates a link by its admin token in the wrapped repository.
+func (r *Repository) PatchByToken(ctx context.Context, token string, patch *link.ValidatedPatch) (err error) {
+	ctx, span := startSpan(ctx, "PatchByToken")
+	defer func() { endSpan(span, err) }()
+	return r.next.PatchByToken(ctx, token, patch)
+}
+
+// ListRevisionsByToken returns the recorded revisions of a link from the wrapped repository.
+func (r *Repository) ListRevisionsByToken(ctx context.Context, token string) (revs []link.Revision, err error) {
+	ctx, span := startSpan(ctx, "ListRevisionsByToken")
+	defer func() { endSpan(span, err) }()
+	return r.next.ListRevisionsByToken(ctx, token)
+}
+
+// GetRevisionByToken retrieves a single revision of a link from the wrapped repository.
+func (r *Repository) GetRevisionByToken(ctx context.Context, token string, number int) (rev *link.Revision, err error) {
+	ctx, span := startSpan(ctx, "GetRevisionByToken", attribute.Int("link.revision", number))
+	defer func() { endSpan(span, err) }()
+	return r.next.GetRevisionByToken(ctx, token, number)
+}
+
+// startSpan starts a client span for the named repository operation.
+// Admin tokens are never recorded as attributes.
+func startSpan(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "link.Repository/"+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// endSpan records err, if any, on the span and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
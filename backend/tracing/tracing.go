@@ -0,0 +1,109 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+const serviceName = "limitlink"
+
+// Setup installs the global tracer provider and W3C trace context propagator
+// configured with environment variables.
+//   - TRACE_EXPORTER: "none" (default), "otlp", "stdout", or "file"
+//   - OTEL_EXPORTER_OTLP_ENDPOINT: collector endpoint used by the otlp exporter
+//   - TRACE_FILE: path written to by the file exporter
+//
+// It returns a function that flushes and stops the exporter.
+func Setup(ctx context.Context) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	exporter, closeOutput, err := newExporter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closeOutput != nil {
+			err = errors.Join(err, closeOutput())
+		}
+		return err
+	}, nil
+}
+
+// newExporter creates the span exporter selected by TRACE_EXPORTER.
+// It returns a nil exporter if tracing is disabled, and a close function if
+// the exporter owns an output file.
+func newExporter(ctx context.Context) (sdktrace.SpanExporter, func() error, error) {
+	kind := strings.ToLower(os.Getenv("TRACE_EXPORTER"))
+
+	switch kind {
+	case "", "none":
+		return nil, nil, nil
+
+	case "otlp":
+		exporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error creating OTLP trace exporter: %w", err)
+		}
+		return exporter, nil, nil
+
+	case "stdout":
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, nil, fmt.Errorf("error creating stdout trace exporter: %w", err)
+		}
+		return exporter, nil, nil
+
+	case "file":
+		path, ok := os.LookupEnv("TRACE_FILE")
+		if !ok || path == "" {
+			return nil, nil, errors.New("missing environment variable: TRACE_FILE")
+		}
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error opening trace file: %w", err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, nil, fmt.Errorf("error creating file trace exporter: %w", err)
+		}
+		return exporter, file.Close, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unrecognized TRACE_EXPORTER %q", kind)
+	}
+}